@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/infocar"
+	"github.com/kapi1023/word-monitor/internal/infocar/fakeinfocar"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:2116", "adres nasłuchu")
+	username := flag.String("username", "test", "login")
+	password := flag.String("password", "test", "hasło")
+	wordsPath := flag.String("words", "", "plik JSON z odpowiedzią word-centers")
+	schedulePath := flag.String("schedule", "", "plik JSON z odpowiedzią exam-schedule")
+	flag.Parse()
+
+	s := fakeinfocar.NewUnstarted(*username, *password)
+	s.SetWords(sampleWords())
+	s.SetSchedule(sampleSchedule(time.Now()))
+	if *wordsPath != "" {
+		var words infocar.AvailableWords
+		if err := readJSON(*wordsPath, &words); err != nil {
+			slog.Error("Błąd wczytywania word-centers", "err", err)
+			os.Exit(1)
+		}
+		s.SetWords(words)
+	}
+	if *schedulePath != "" {
+		var schedule infocar.ExamScheduleResponse
+		if err := readJSON(*schedulePath, &schedule); err != nil {
+			slog.Error("Błąd wczytywania exam-schedule", "err", err)
+			os.Exit(1)
+		}
+		s.SetSchedule(schedule)
+	}
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		slog.Error("Błąd nasłuchu", "err", err)
+		os.Exit(1)
+	}
+	s.Listener.Close()
+	s.Listener = l
+	s.Start()
+	defer s.Close()
+	slog.Info("Fałszywy info-car uruchomiony, ustaw monitor.base_url", "url", s.URL)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	<-stop
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func sampleWords() infocar.AvailableWords {
+	return infocar.AvailableWords{
+		Provinces: []infocar.Provinces{
+			{ID: 7, Name: "mazowieckie", Latitude: "52.2297", Longitude: "21.0122", Zoom: 8},
+		},
+		Words: []infocar.Word{
+			{ID: 1, Name: "WORD Warszawa", Address: "ul. Odlewnicza 8, 03-231 Warszawa", Latitude: "52.3145", Longitude: "21.0035", ProvinceID: 7},
+		},
+	}
+}
+
+func sampleSchedule(now time.Time) infocar.ExamScheduleResponse {
+	day := now.AddDate(0, 0, 3).Format("2006-01-02")
+	return infocar.ExamScheduleResponse{
+		Category:       "B",
+		OrganizationId: "1",
+		Schedule: infocar.Schedule{
+			ScheduledDays: []infocar.ScheduleDays{{
+				Day: day,
+				ScheduledHours: []infocar.ScheduledHours{{
+					Time: "08:00:00",
+					PracticeExams: []infocar.PracticeExams{
+						{ID: "fake-practice-1", Places: 1, Date: day + "T08:00:00", Amount: 200},
+					},
+					TheoryExams: []infocar.TheoryExams{
+						{ID: "fake-theory-1", Places: 5, Date: day + "T08:00:00", Amount: 50},
+					},
+				}},
+			}},
+		},
+	}
+}
@@ -1,38 +1,74 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
 	"time"
 
-	"github.com/kapi1023/word-monitor/internal/cache"
+	"github.com/kapi1023/word-monitor/internal/api"
+	"github.com/kapi1023/word-monitor/internal/catalog"
 	"github.com/kapi1023/word-monitor/internal/config"
+	"github.com/kapi1023/word-monitor/internal/heartbeat"
+	"github.com/kapi1023/word-monitor/internal/httpclient"
 	"github.com/kapi1023/word-monitor/internal/infocar"
+	"github.com/kapi1023/word-monitor/internal/logging"
 	"github.com/kapi1023/word-monitor/internal/monitor"
+	"github.com/kapi1023/word-monitor/internal/source"
+	"github.com/kapi1023/word-monitor/internal/source/infocarsource"
 	"github.com/kapi1023/word-monitor/internal/state"
+	"github.com/kapi1023/word-monitor/internal/tracing"
+	"github.com/kapi1023/word-monitor/internal/tui"
 	"github.com/kapi1023/word-monitor/internal/webhook"
 )
 
 const (
-	path      = "internal/config/config.yaml"
-	statePath = "internal/state/state.enc"
+	path               = "internal/config/config.yaml"
+	defaultStatePath   = "internal/state/state.enc"
+	defaultBoltPath    = "internal/state/state.db"
+	defaultCatalogPath = "internal/state/catalog.json"
+	defaultSessionPath = "internal/state/session.enc"
 )
 
 func main() {
+	dashboard := flag.Bool("tui", false, "uruchom monitoring od razu w panelu terminalowym")
+	logFormat := flag.String("log-format", "", "format logów: text lub json (domyślnie z konfiguracji)")
+	logLevel := flag.String("log-level", "", "poziom logów: debug, info, warn, error (domyślnie z konfiguracji)")
+	flag.Parse()
+
+	// Until the config is loaded only the flags are known.
+	if err := configureLogging(&config.Config{}, *logFormat, *logLevel); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
 	configPath := os.Getenv("CONFIG_PATH")
 	if configPath == "" {
 		configPath = path
 	}
 	slog.Info("Używana konfiguracja", "path", configPath)
-	statePath := os.Getenv("STATE_PATH")
-	if statePath == "" {
-		statePath = statePath
+	catalogPath := os.Getenv("CATALOG_PATH")
+	if catalogPath == "" {
+		catalogPath = defaultCatalogPath
+	}
+	vaultPath := os.Getenv("VAULT_PATH")
+	if vaultPath == "" {
+		vaultPath = defaultVaultPath
 	}
-	slog.Info("Używana konfiguracja", "path", statePath)
 
 	cfg, err := config.Load(configPath)
+	if err != nil && (flag.Arg(0) == "state" || flag.Arg(0) == "vault") {
+		// Subcommands are run from scripts, so they never start the editor.
+		if errors.Is(err, fs.ErrNotExist) {
+			err = fmt.Errorf("nie znaleziono konfiguracji %s", configPath)
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 	if err != nil {
 		slog.Error("Błąd ładowania konfiguracji", "err", err)
 		cfg = config.NewConfig()
@@ -45,21 +81,94 @@ func main() {
 	} else {
 		slog.Info("Wczytano konfigurację")
 	}
-	level := slog.LevelInfo
-	if cfg.Monitor.Debug {
-		level = slog.LevelDebug
+
+	if flag.Arg(0) == "vault" {
+		if err := runVaultCommand(vaultPath, cfg, configPath, flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := unlockSecrets(cfg, vaultPath); err != nil {
+		slog.Error("Błąd odczytu sejfu", "path", vaultPath, "err", err)
+		os.Exit(1)
+	}
+	if err := configureLogging(cfg, *logFormat, *logLevel); err != nil {
+		slog.Error("Błąd konfiguracji logów", "err", err)
 	}
 
-	storage, err := state.New(statePath, cfg.State.SecretKey)
+	// The bolt database gets its own default file, so switching the backend
+	// does not make bbolt open the JSON store.
+	statePath := os.Getenv("STATE_PATH")
+	if statePath == "" {
+		statePath = defaultStatePath
+		if cfg.State.Backend == state.BackendBolt {
+			statePath = defaultBoltPath
+		}
+	}
+	slog.Info("Używany magazyn stanu", "backend", cfg.State.Backend, "path", statePath)
+	storage, err := state.Open(cfg.State, statePath)
 	if err != nil {
 		slog.Error("Błąd inicjalizacji state storage", "err", err)
 		os.Exit(1)
 	}
 
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
-	slog.SetDefault(logger)
-	reader := bufio.NewScanner(os.Stdin)
-	c := cache.New[infocar.Word]()
+	if flag.Arg(0) == "state" {
+		err := runStateCommand(storage, flag.Args()[1:])
+		if cerr := storage.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		slog.Error("Błąd konfiguracji śledzenia", "err", err)
+		shutdownTracing = func(context.Context) error { return nil }
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Warn("Błąd wysyłki śladów", "err", err)
+		}
+	}()
+
+	clients, err := httpclient.New(cfg)
+	if err != nil {
+		slog.Error("Błąd konfiguracji klienta HTTP", "err", err)
+		os.Exit(1)
+	}
+	webhook.SetClient(clients.Plain)
+	heartbeat.SetClient(clients.Plain)
+	client := infocar.NewCLient(cfg.InfocarBaseUrl(), clients.Infocar)
+	client.OnLogin(clients.RotateUserAgent)
+	if err := recordTraffic(cfg, client); err != nil {
+		slog.Error("Błąd konfiguracji nagrywania ruchu info-car", "err", err)
+		os.Exit(1)
+	}
+	persistSession(cfg, client)
+	words := catalog.New(catalogPath, client)
+	validateTargets(cfg, words)
+	src := infocarsource.New(client, words, cfg.CatalogRefresh())
+
+	server := api.New(cfg, configPath, storage, words)
+	if cfg.API.Address != "" {
+		go func() {
+			if err := server.ListenAndServe(cfg.API); err != nil {
+				slog.Error("Błąd API HTTP", "err", err)
+			}
+		}()
+	}
+
+	if *dashboard {
+		startDashboard(cfg, configPath, vaultPath, src, storage, words, server)
+		return
+	}
 
 	for {
 		fmt.Println("\n--- WORD MONITOR ---")
@@ -68,23 +177,32 @@ func main() {
 		fmt.Println("3. Edytuj konfigurację")
 		fmt.Println("4. Zapisz konfigurację")
 		fmt.Println("5. Pokaz dostepne wordy")
-		fmt.Println("6. Pokaz dostepne wordy w województwie")
+		fmt.Println("6. Szukaj WORDu (nazwa, miasto, województwo)")
 		fmt.Println("7. Pokaz wojewodztwa")
-		fmt.Println("5. Wyjdź")
+		fmt.Println("8. Panel monitoringu (TUI)")
+		fmt.Println("9. Wyjdź")
 		fmt.Print("Wybierz opcję: ")
 
-		if !reader.Scan() {
+		choice, ok := readLine()
+		if !ok {
 			break
 		}
-		choice := reader.Text()
 
 		switch choice {
 		case "1":
-			startMonitoring(cfg, storage, c)
+			startMonitoring(cfg, src, storage, words, server)
 		case "2":
 			cfg.Show()
 		case "3":
 			cfg.Edit()
+			if err := unlockSecrets(cfg, vaultPath); err != nil {
+				slog.Error("Błąd odczytu sejfu", "path", vaultPath, "err", err)
+			}
+			validateTargets(cfg, words)
+			if err := configureLogging(cfg, *logFormat, *logLevel); err != nil {
+				slog.Error("Błąd konfiguracji logów", "err", err)
+			}
+			server.SetConfig(*cfg)
 		case "4":
 			if err := cfg.Save(configPath); err != nil {
 				slog.Error("Błąd zapisu konfiguracji", "err", err)
@@ -92,40 +210,42 @@ func main() {
 				slog.Info("Konfiguracja zapisana")
 			}
 		case "5":
-			words, err := infocar.GetWords()
-			if err != nil || words == nil {
+			if err := words.EnsureFresh(context.Background(), cfg.CatalogRefresh()); err != nil {
 				slog.Error("Błąd pobierania dostępnych WORDów", "err", err)
 			}
 			fmt.Println("--- DOSTĘPNE WORDY ---")
-			for _, word := range words {
-				fmt.Printf("ID: %d, Nazwa: %s\n", word.ID, word.Name)
+			for _, word := range words.Words() {
+				fmt.Printf("ID: %d, Nazwa: %s%s\n", word.ID, word.Name, offlineLabel(word))
 			}
 		case "6":
-			fmt.Println("Podaj nazwe województwa:")
-			if !reader.Scan() {
+			fmt.Println("Podaj nazwę WORDu, miasto lub województwo:")
+			query, ok := readLine()
+			if !ok {
 				break
 			}
-			provinceName := reader.Text()
-			words, err := infocar.GetWordsByProvince(provinceName)
-			if err != nil || words == nil {
-				slog.Error("Błąd pobierania dostępnych WORDów w regionie", "err", err)
+			if err := words.EnsureFresh(context.Background(), cfg.CatalogRefresh()); err != nil {
+				slog.Error("Błąd pobierania dostępnych WORDów", "err", err)
 			}
-			fmt.Println("--- DOSTĘPNE WORDY W WOJEWÓDZTWIE ---")
-			for _, word := range words {
-				fmt.Printf("ID: %v, Nazwa: %s\n", word.ID, word.Name)
+			fmt.Println("--- ZNALEZIONE WORDY ---")
+			for _, m := range words.Search(query, 20) {
+				fmt.Printf("ID: %d, Nazwa: %s%s, Województwo: %s, Adres: %s\n", m.Word.ID, m.Word.Name, offlineLabel(m.Word), m.Province, m.Word.Address)
 			}
 		case "7":
-			regions, err := infocar.GetProvinces()
-			if err != nil || regions == nil {
+			if err := words.EnsureFresh(context.Background(), cfg.CatalogRefresh()); err != nil {
 				slog.Error("Błąd pobierania dostępnych województw", "err", err)
 			}
 			fmt.Println("--- DOSTĘPNE WOJEWÓDZTWA ---")
-			for _, region := range regions {
-				fmt.Printf("Nazwa: %s\n", region)
+			for _, region := range words.Provinces() {
+				fmt.Printf("Nazwa: %s\n", region.Name)
 			}
 		case "8":
+			startDashboard(cfg, configPath, vaultPath, src, storage, words, server)
+		case "9":
 			fmt.Println("--- EXIT ---")
-			os.Exit(0)
+			if err := storage.Close(); err != nil {
+				slog.Error("Błąd zamykania stanu", "err", err)
+			}
+			return
 
 		default:
 			fmt.Println("Nieprawidłowa opcja.")
@@ -133,38 +253,113 @@ func main() {
 	}
 }
 
-func startMonitoring(cfg *config.Config, storage *state.Storage, c *cache.Cache[infocar.Word]) {
-	slog.Info("Rozpoczęcie monitoringu...")
-	client := infocar.NewCLient()
-	if err := client.Login(cfg.Credential.Username, cfg.Credential.Password); err != nil {
-		slog.Error("Błąd logowania", "err", err)
+func offlineLabel(word infocar.Word) string {
+	if word.Offline {
+		return " (offline)"
+	}
+	return ""
+}
+
+// validateTargets checks the configured WORD IDs against the catalog and
+// replaces centers given by name with their IDs.
+func validateTargets(cfg *config.Config, words *catalog.Catalog) {
+	if len(cfg.WatchTargets()) == 0 {
 		return
 	}
+	if err := words.EnsureFresh(context.Background(), cfg.CatalogRefresh()); err != nil {
+		slog.Warn("Nie można sprawdzić WORDów w konfiguracji", "err", err)
+		return
+	}
+	errs := words.ValidateTargets(cfg.Targets)
+	if cfg.Word.WordId != "" {
+		single := []config.WORD{cfg.Word}
+		errs = append(errs, words.ValidateTargets(single)...)
+		cfg.Word = single[0]
+	}
+	for _, err := range errs {
+		slog.Warn("Nieprawidłowy WORD w konfiguracji", "err", err)
+	}
+}
 
-	slog.Info("Zalogowano pomyślnie. Start monitoringu...")
-	var i int
-	for {
-		found, _, err := monitor.Check(cfg, client, storage, c)
-		if err != nil {
-			if err.Error() == "token is empty or expired" {
-				slog.Info("Token wygasł, ponowne logowanie...")
-				if err := client.Login(cfg.Credential.Username, cfg.Credential.Password); err != nil {
-					slog.Error("Błąd logowania", "err", err)
-					return
-				}
-				slog.Info("Zalogowano pomyślnie. Start monitoringu...")
-				continue
-			}
-			slog.Error("Błąd podczas sprawdzania dostępności", "err", err)
+// recordTraffic makes the client replay or record its exchanges with
+// info-car when the config asks for it.
+func recordTraffic(cfg *config.Config, client *infocar.InfocarClient) error {
+	if dir := cfg.Monitor.ReplayDir; dir != "" {
+		if err := client.Replay(dir); err != nil {
+			return err
 		}
-		if !found {
-			slog.Debug("Brak dostępnych terminów")
+		slog.Warn("Tryb odtwarzania, info-car nie jest odpytywany", "dir", dir)
+	} else if dir := cfg.Monitor.RecordDir; dir != "" {
+		if err := client.Record(dir); err != nil {
+			return err
 		}
-		time.Sleep(time.Duration(cfg.Monitor.Interval) * time.Second)
-		i++
-		if cfg.Monitor.HealthCheckInterval != 0 && i%cfg.Monitor.HealthCheckInterval == 0 {
-			webhook.Send(cfg.Webhook.DiscordHealthCheckUrl, "Health check")
-			slog.Debug("Wysłano health check")
+		slog.Info("Nagrywanie ruchu info-car", "dir", dir)
+	}
+	return nil
+}
+
+// persistSession lets the client keep its session across restarts when
+// state.secret_key is set. Replayed traffic never touches the saved session.
+func persistSession(cfg *config.Config, client *infocar.InfocarClient) {
+	if cfg.State.SecretKey == "" || cfg.Monitor.ReplayDir != "" {
+		return
+	}
+	path := os.Getenv("SESSION_PATH")
+	if path == "" {
+		path = defaultSessionPath
+	}
+	restored, err := client.PersistSession(path, cfg.State.SecretKey)
+	switch {
+	case err != nil:
+		slog.Warn("Nie udało się odczytać zapisanej sesji info-car", "path", path, "err", err)
+	case restored:
+		slog.Info("Przywrócono zapisaną sesję info-car", "path", path)
+	}
+}
+
+// configureLogging applies the log format and level, flags taking precedence
+// over the config, and registers the configured secrets for redaction.
+func configureLogging(cfg *config.Config, format, level string) error {
+	logging.AddSecrets(cfg.Secrets()...)
+	if format == "" {
+		format = cfg.Log.Format
+	}
+	if level == "" {
+		level = cfg.Log.Level
+	}
+	if level == "" && cfg.Monitor.Debug {
+		level = "debug"
+	}
+	l, err := logging.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	return logging.Setup(os.Stdout, format, l)
+}
+
+func startMonitoring(cfg *config.Config, src source.SlotSource, storage state.Store, words *catalog.Catalog, server *api.Server) {
+	poller := monitor.NewPoller(cfg, src, storage, words)
+	server.Attach(poller)
+	if err := poller.Run(); err != nil {
+		slog.Error("Błąd monitoringu", "err", err)
+	}
+	*cfg = poller.Config()
+	server.SetConfig(*cfg)
+}
+
+func startDashboard(cfg *config.Config, configPath, vaultPath string, src source.SlotSource, storage state.Store, words *catalog.Catalog, server *api.Server) {
+	poller := monitor.NewPoller(cfg, src, storage, words)
+	server.Attach(poller)
+	d := tui.New(configPath, poller, storage, words)
+	d.AfterEdit = func(cfg *config.Config) {
+		if err := unlockSecrets(cfg, vaultPath); err != nil {
+			slog.Error("Błąd odczytu sejfu", "path", vaultPath, "err", err)
 		}
+		validateTargets(cfg, words)
+	}
+	if err := d.Run(); err != nil {
+		slog.Error("Błąd panelu monitoringu", "err", err)
 	}
+	*cfg = poller.Config()
+	server.SetConfig(*cfg)
 }
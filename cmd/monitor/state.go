@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/state"
+)
+
+const stateUsage = `Użycie: monitor state <polecenie>
+  list [klucz]                  terminy zapisane dla klucza (WORD:kategoria) lub wszystkich kluczy
+  forget <klucz> [dzień godz.]  zapomnij termin lub cały klucz, aby ponownie o nim powiadomić
+  prune [dzień]                 usuń terminy sprzed dnia (RRRR-MM-DD, domyślnie dziś)
+  export [plik]                 zapisz stan jako JSON (domyślnie na standardowe wyjście)
+  import <plik>                 wczytaj stan z JSON, zastępując klucze obecne w pliku
+  stats                         podsumowanie stanu per klucz`
+
+// runStateCommand handles "monitor state ...". With the file and bolt
+// backends the monitor should not run at the same time.
+func runStateCommand(storage state.Store, args []string) error {
+	if len(args) == 0 {
+		return errors.New(stateUsage)
+	}
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "list":
+		keys := args
+		if len(keys) == 0 {
+			var err error
+			if keys, err = storage.Keys(); err != nil {
+				return err
+			}
+		}
+		for _, key := range keys {
+			slots, err := storage.Get(key)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("--- %s (%d) ---\n", key, len(slots))
+			for _, slot := range slots {
+				status := "aktywny"
+				if !slot.Active() {
+					status = "zniknął " + slot.GoneAt.Local().Format("2006-01-02 15:04")
+				}
+				fmt.Printf("%s %s  praktyczne: %d, teoretyczne: %d, powiadomiono: %s, %s\n",
+					slot.Day, slot.Time, len(slot.PracticeIDs), len(slot.TheoryIDs), slot.NotifiedAt.Local().Format("2006-01-02 15:04"), status)
+			}
+		}
+	case "forget":
+		switch len(args) {
+		case 1:
+			if err := storage.Delete(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Zapomniano klucz %s\n", args[0])
+		case 3:
+			ok, err := state.Forget(storage, args[0], args[1], args[2])
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("brak terminu %s %s dla klucza %s", args[1], args[2], args[0])
+			}
+			fmt.Printf("Zapomniano termin %s %s dla klucza %s\n", args[1], args[2], args[0])
+		default:
+			return errors.New(stateUsage)
+		}
+	case "prune":
+		before := time.Now()
+		if len(args) > 0 {
+			var err error
+			if before, err = time.Parse("2006-01-02", args[0]); err != nil {
+				return fmt.Errorf("nieprawidłowy dzień %q, oczekiwano RRRR-MM-DD", args[0])
+			}
+		}
+		n, err := state.Prune(storage, before)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Usunięto %d terminów sprzed %s\n", n, before.Format("2006-01-02"))
+	case "export":
+		out := os.Stdout
+		if len(args) > 0 {
+			f, err := os.Create(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+		return state.Export(storage, out)
+	case "import":
+		if len(args) != 1 {
+			return errors.New(stateUsage)
+		}
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		n, err := state.Import(storage, f)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Zaimportowano %d terminów\n", n)
+	case "stats":
+		stats, err := state.Stats(storage)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%-12s %8s %8s  %-10s  %-10s  %s\n", "KLUCZ", "AKTYWNE", "ZNIKŁE", "OD", "DO", "OSTATNIE POWIADOMIENIE")
+		for _, ks := range stats {
+			notified := "-"
+			if !ks.Notified.IsZero() {
+				notified = ks.Notified.Local().Format("2006-01-02 15:04")
+			}
+			fmt.Printf("%-12s %8d %8d  %-10s  %-10s  %s\n", ks.Key, ks.Active, ks.Gone, ks.First, ks.Last, notified)
+		}
+	default:
+		return fmt.Errorf("nieznane polecenie %q\n%s", strings.Join(append([]string{cmd}, args...), " "), stateUsage)
+	}
+	return nil
+}
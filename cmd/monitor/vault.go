@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/kapi1023/word-monitor/internal/config"
+	"github.com/kapi1023/word-monitor/internal/vault"
+)
+
+const defaultVaultPath = "internal/state/vault.enc"
+
+const vaultUsage = `Użycie: monitor vault <polecenie>
+  list                    nazwy sekretów w sejfie
+  set <nazwa> [wartość]   zapisz sekret (bez wartości zostanie wczytany bez echa)
+  delete <nazwa>          usuń sekret
+  migrate                 przenieś jawne sekrety z konfiguracji do sejfu i zapisz w niej odwołania
+
+Hasło sejfu jest brane z ` + vault.EnvKey + ` albo wczytywane z terminala.
+W konfiguracji sekret wskazuje się wartością "` + config.VaultPrefix + `<nazwa>".`
+
+// runVaultCommand handles "monitor vault ...".
+func runVaultCommand(path string, cfg *config.Config, configPath string, args []string) error {
+	if len(args) == 0 {
+		return errors.New(vaultUsage)
+	}
+	v, err := openVault(path)
+	if err != nil {
+		return err
+	}
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "list":
+		for _, name := range v.Names() {
+			fmt.Println(name)
+		}
+		return nil
+	case "set":
+		if len(args) == 0 || len(args) > 2 {
+			return errors.New(vaultUsage)
+		}
+		value := ""
+		if len(args) == 2 {
+			value = args[1]
+		} else if value, err = readSecret("Wartość " + args[0] + ": "); err != nil {
+			return err
+		}
+		v.Set(args[0], value)
+		if err := v.Save(); err != nil {
+			return err
+		}
+		fmt.Printf("Zapisano sekret %s, w konfiguracji użyj %s%s\n", args[0], config.VaultPrefix, args[0])
+	case "delete":
+		if len(args) != 1 {
+			return errors.New(vaultUsage)
+		}
+		if !v.Delete(args[0]) {
+			return fmt.Errorf("brak sekretu %s", args[0])
+		}
+		if err := v.Save(); err != nil {
+			return err
+		}
+		fmt.Printf("Usunięto sekret %s\n", args[0])
+	case "migrate":
+		if err := cfg.ResolveSecrets(v.Get); err != nil {
+			return err
+		}
+		moved := cfg.MoveSecrets(v.Set)
+		if len(moved) == 0 {
+			fmt.Println("Konfiguracja nie zawiera jawnych sekretów")
+			return nil
+		}
+		// The vault is written first, so the config never refers to
+		// secrets that were not saved.
+		if err := v.Save(); err != nil {
+			return err
+		}
+		if err := cfg.Save(configPath); err != nil {
+			return err
+		}
+		fmt.Printf("Przeniesiono do sejfu: %s\n", strings.Join(moved, ", "))
+	default:
+		return errors.New(vaultUsage)
+	}
+	return nil
+}
+
+// stdin is shared by the menu and the secret prompts, so input piped to the
+// monitor is not lost in a reader that is thrown away.
+var stdin = bufio.NewReader(os.Stdin)
+
+// opened keeps the vault unlocked for the rest of the session, so editing the
+// config does not ask for the passphrase again.
+var opened struct {
+	path  string
+	vault *vault.Vault
+}
+
+// readLine reads a line from stdin; ok is false once the input has ended.
+func readLine() (line string, ok bool) {
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", false
+	}
+	return strings.TrimRight(line, "\r\n"), true
+}
+
+// unlockSecrets replaces vault references in cfg with the stored secrets. The
+// vault is opened only when the config refers to it.
+func unlockSecrets(cfg *config.Config, path string) error {
+	if len(cfg.VaultRefs()) == 0 {
+		return nil
+	}
+	v, err := openVault(path)
+	if err != nil {
+		return err
+	}
+	return cfg.ResolveSecrets(v.Get)
+}
+
+func openVault(path string) (*vault.Vault, error) {
+	if opened.vault != nil && opened.path == path {
+		return opened.vault, nil
+	}
+	passphrase := os.Getenv(vault.EnvKey)
+	if passphrase == "" {
+		var err error
+		if passphrase, err = readSecret("Hasło sejfu: "); err != nil {
+			return nil, err
+		}
+		if _, statErr := os.Stat(path); errors.Is(statErr, os.ErrNotExist) {
+			again, err := readSecret("Powtórz hasło nowego sejfu: ")
+			if err != nil {
+				return nil, err
+			}
+			if again != passphrase {
+				return nil, errors.New("hasła nie są zgodne")
+			}
+		}
+	}
+	v, err := vault.Open(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	opened.path, opened.vault = path, v
+	return v, nil
+}
+
+// readSecret reads a line without echo from the terminal, or a plain line
+// when stdin is not one.
+func readSecret(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, ok := readLine()
+		if !ok {
+			return "", io.EOF
+		}
+		return line, nil
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	value, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return string(value), err
+}
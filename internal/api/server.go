@@ -0,0 +1,175 @@
+// Package api serves the monitor state over HTTP.
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/calendar"
+	"github.com/kapi1023/word-monitor/internal/catalog"
+	"github.com/kapi1023/word-monitor/internal/config"
+	"github.com/kapi1023/word-monitor/internal/monitor"
+	"github.com/kapi1023/word-monitor/internal/state"
+)
+
+type Server struct {
+	configPath string
+	storage    state.Store
+	catalog    *catalog.Catalog
+	mux        *http.ServeMux
+
+	mu     sync.Mutex
+	cfg    config.Config
+	poller *monitor.Poller
+}
+
+// errNotMonitoring rejects configuration changes while no poller owns the
+// configuration, e.g. when the main menu is shown.
+var errNotMonitoring = errors.New("konfigurację można zmienić przez panel tylko podczas monitoringu")
+
+func New(cfg *config.Config, configPath string, storage state.Store, words *catalog.Catalog) *Server {
+	s := &Server{cfg: *cfg, configPath: configPath, storage: storage, catalog: words, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /calendar.ics", s.handleCalendar)
+	s.routeWeb()
+	return s
+}
+
+// Attach makes the server report the targets of a running poller, which may
+// differ from the configuration once targets are added at runtime, and send
+// configuration changes to it.
+func (s *Server) Attach(p *monitor.Poller) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.poller = p
+}
+
+// SetConfig detaches the poller and serves a copy of cfg until the next
+// Attach. The configuration is read-only through the API meanwhile.
+func (s *Server) SetConfig(cfg config.Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.poller = nil
+	s.cfg = cfg
+}
+
+// attached returns the poller, or nil and the configuration served without
+// one. Calls into the poller are made without holding s.mu, as they may wait
+// for a poll cycle.
+func (s *Server) attached() (*monitor.Poller, config.Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.poller, s.cfg
+}
+
+func (s *Server) targets() []config.WORD {
+	p, cfg := s.attached()
+	if p != nil {
+		return p.Targets()
+	}
+	return cfg.WatchTargets()
+}
+
+func (s *Server) config() config.Config {
+	p, cfg := s.attached()
+	if p != nil {
+		return p.Config()
+	}
+	return cfg
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	password := s.config().API.Password
+	if password == "" && !loopbackHost(r.Host) {
+		// Without a password only names of the loopback interface are served,
+		// so a page that rebinds its own domain to 127.0.0.1 cannot use the API.
+		http.Error(w, "nieprawidłowy nagłówek Host", http.StatusForbidden)
+		return
+	}
+	if password != "" {
+		_, given, _ := r.BasicAuth()
+		if subtle.ConstantTimeCompare([]byte(given), []byte(password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="word-monitor", charset="UTF-8"`)
+			http.Error(w, "wymagane hasło", http.StatusUnauthorized)
+			return
+		}
+	}
+	if !safeMethod(r.Method) && !sameOrigin(r) {
+		http.Error(w, "żądanie z innej strony odrzucone", http.StatusForbidden)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// loopbackHost reports whether the Host header names the loopback interface,
+// with or without a port.
+func loopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// sameOrigin rejects requests a browser sends on behalf of another site.
+// Clients other than browsers send neither header and are let through.
+func sameOrigin(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// ListenAndServe serves the API on cfg's address. Without a password it
+// refuses to listen beyond the loopback interface.
+func (s *Server) ListenAndServe(cfg config.API) error {
+	addr := cfg.ListenAddress()
+	if cfg.Public() && cfg.Password == "" {
+		return fmt.Errorf("API na %s byłoby dostępne bez hasła spoza komputera, ustaw api.password", addr)
+	}
+	slog.Info("API HTTP uruchomione", "addr", addr)
+	srv := &http.Server{Addr: addr, Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	return srv.ListenAndServe()
+}
+
+// handleCalendar serves the iCalendar feed. The optional word and category
+// query parameters limit it to matching targets.
+func (s *Server) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	word, category := r.URL.Query().Get("word"), r.URL.Query().Get("category")
+	var targets []config.WORD
+	for _, t := range s.targets() {
+		if (word == "" || t.WordId == word) && (category == "" || t.Category == category) {
+			targets = append(targets, t)
+		}
+	}
+	data, err := calendar.Build(targets, s.storage, s.catalog, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", calendar.ContentType)
+	w.Header().Set("Content-Disposition", `inline; filename="word-monitor.ics"`)
+	_, _ = w.Write(data)
+}
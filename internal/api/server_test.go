@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kapi1023/word-monitor/internal/config"
+)
+
+func TestServeHTTPGuards(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		method   string
+		host     string
+		header   http.Header
+		auth     bool
+		want     int
+	}{
+		{name: "localhost", method: http.MethodGet, host: "localhost:2115", want: http.StatusOK},
+		{name: "ipv4 loopback", method: http.MethodGet, host: "127.0.0.1:2115", want: http.StatusOK},
+		{name: "ipv6 loopback", method: http.MethodGet, host: "[::1]:2115", want: http.StatusOK},
+		{name: "rebound domain", method: http.MethodGet, host: "evil.example:2115", want: http.StatusForbidden},
+		{name: "rebound domain put", method: http.MethodPut, host: "evil.example:2115", want: http.StatusForbidden},
+		{name: "other host with password", password: "secret", auth: true, method: http.MethodGet, host: "monitor.lan:2115", want: http.StatusOK},
+		{name: "missing password", password: "secret", method: http.MethodGet, host: "localhost:2115", want: http.StatusUnauthorized},
+		// Without a poller an accepted PUT ends with 409.
+		{name: "put without browser headers", method: http.MethodPut, host: "localhost:2115", want: http.StatusConflict},
+		{name: "put same origin", method: http.MethodPut, host: "localhost:2115", header: http.Header{"Origin": {"http://localhost:2115"}, "Sec-Fetch-Site": {"same-origin"}}, want: http.StatusConflict},
+		{name: "put cross site", method: http.MethodPut, host: "localhost:2115", header: http.Header{"Sec-Fetch-Site": {"cross-site"}}, want: http.StatusForbidden},
+		{name: "put other origin", method: http.MethodPut, host: "localhost:2115", header: http.Header{"Origin": {"http://evil.example"}}, want: http.StatusForbidden},
+		{name: "put other origin with password", password: "secret", auth: true, method: http.MethodPut, host: "monitor.lan:2115", header: http.Header{"Origin": {"http://evil.example"}}, want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.API.Password = tt.password
+			s := New(cfg, "", nil, nil)
+
+			r := httptest.NewRequest(tt.method, "/api/config", strings.NewReader("{}"))
+			r.Host = tt.host
+			for k, v := range tt.header {
+				r.Header[k] = v
+			}
+			if tt.auth {
+				r.SetBasicAuth("admin", tt.password)
+			}
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
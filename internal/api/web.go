@@ -0,0 +1,163 @@
+package api
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kapi1023/word-monitor/internal/config"
+	"github.com/kapi1023/word-monitor/internal/infocar"
+	"github.com/kapi1023/word-monitor/internal/state"
+)
+
+//go:embed web
+var web embed.FS
+
+const maxConfigBody = 1 << 20
+
+type wordView struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Address   string `json:"address"`
+	Province  string `json:"province"`
+	Latitude  string `json:"latitude"`
+	Longitude string `json:"longitude"`
+	Offline   bool   `json:"offline"`
+}
+
+type targetSlots struct {
+	Target  config.WORD      `json:"target"`
+	Name    string           `json:"name"`
+	Address string           `json:"address"`
+	Slots   []state.ExamSlot `json:"slots"`
+}
+
+func (s *Server) routeWeb() {
+	static, _ := fs.Sub(web, "web")
+	s.mux.Handle("GET /", http.FileServerFS(static))
+	s.mux.HandleFunc("GET /api/config", s.handleGetConfig)
+	s.mux.HandleFunc("PUT /api/config", s.handlePutConfig)
+	s.mux.HandleFunc("GET /api/words", s.handleWords)
+	s.mux.HandleFunc("GET /api/slots", s.handleSlots)
+}
+
+// masked hides secrets from the browser. Empty secrets sent back keep the
+// stored value.
+func masked(cfg config.Config) config.Config {
+	cfg.ClearSecrets()
+	return cfg
+}
+
+func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, masked(s.config()))
+}
+
+func (s *Server) handlePutConfig(w http.ResponseWriter, r *http.Request) {
+	poller, _ := s.attached()
+	if poller == nil {
+		writeErrors(w, http.StatusConflict, []error{errNotMonitoring})
+		return
+	}
+	current := poller.Config()
+	next := current
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxConfigBody)).Decode(&next); err != nil {
+		writeErrors(w, http.StatusBadRequest, []error{err})
+		return
+	}
+	next.KeepSecrets(&current)
+	if changed := next.LocalChanges(&current); len(changed) > 0 {
+		writeErrors(w, http.StatusForbidden, []error{fmt.Errorf("tych ustawień nie można zmienić przez panel, tylko w pliku konfiguracji: %s", strings.Join(changed, ", "))})
+		return
+	}
+
+	errs := next.Validate()
+	if len(s.catalog.Words()) > 0 {
+		errs = append(errs, s.catalog.ValidateTargets(next.Targets)...)
+		if next.Word.WordId != "" {
+			single := []config.WORD{next.Word}
+			errs = append(errs, s.catalog.ValidateTargets(single)...)
+			next.Word = single[0]
+		}
+	}
+	if len(errs) > 0 {
+		writeErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	poller.Reconfigure(next)
+	if err := next.Save(s.configPath); err != nil {
+		writeErrors(w, http.StatusInternalServerError, []error{errors.New("konfiguracja zastosowana, ale nie zapisana: " + err.Error())})
+		return
+	}
+	writeJSON(w, http.StatusOK, masked(next))
+}
+
+// handleWords lists the catalog, or the best matches for the q parameter.
+func (s *Server) handleWords(w http.ResponseWriter, r *http.Request) {
+	views := []wordView{}
+	if q := r.URL.Query().Get("q"); q != "" {
+		for _, m := range s.catalog.Search(q, 50) {
+			views = append(views, s.wordView(m.Word))
+		}
+	} else {
+		for _, word := range s.catalog.Words() {
+			views = append(views, s.wordView(word))
+		}
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) wordView(word infocar.Word) wordView {
+	return wordView{
+		ID:        word.ID,
+		Name:      word.Name,
+		Address:   word.Address,
+		Province:  s.catalog.ProvinceName(word.ProvinceID),
+		Latitude:  word.Latitude,
+		Longitude: word.Longitude,
+		Offline:   word.Offline,
+	}
+}
+
+// handleSlots returns the stored slots of every target, including the ones
+// that are gone, which carry gone_at.
+func (s *Server) handleSlots(w http.ResponseWriter, r *http.Request) {
+	result := []targetSlots{}
+	for _, t := range s.targets() {
+		slots, err := s.storage.Get(state.Key(t.WordId, t.Category))
+		if err != nil {
+			writeErrors(w, http.StatusInternalServerError, []error{err})
+			return
+		}
+		ts := targetSlots{Target: t, Name: "WORD " + t.WordId, Slots: slots}
+		if id, err := strconv.Atoi(t.WordId); err == nil {
+			if word, ok := s.catalog.ByID(id); ok {
+				ts.Name, ts.Address = word.Name, word.Address
+			}
+		}
+		if ts.Slots == nil {
+			ts.Slots = []state.ExamSlot{}
+		}
+		result = append(result, ts)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeErrors(w http.ResponseWriter, status int, errs []error) {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	writeJSON(w, status, map[string][]string{"errors": msgs})
+}
@@ -0,0 +1,265 @@
+"use strict";
+
+// Fields of config.Config shown in the settings form, grouped like the
+// terminal editor. Secrets are never sent to the browser; leaving them empty
+// keeps the stored value. Paths and the info-car address are only editable in
+// the config file.
+const sections = [
+  ["Konto info-car", [
+    ["Credential.Username", "Login", "text"],
+    ["Credential.Password", "Hasło (puste = bez zmian)", "password"],
+    ["Credential.Pesel", "PESEL (puste = bez zmian)", "password"],
+    ["Credential.PKK", "PKK (puste = bez zmian)", "password"],
+    ["Credential.Email", "Email", "text"],
+    ["Credential.Phone", "Telefon", "text"],
+  ]],
+  ["Egzaminy", [
+    ["Monitor.PracticeExams", "Egzaminy praktyczne", "bool"],
+    ["Monitor.TheoryExams", "Egzaminy teoretyczne", "bool"],
+    ["Filter.MinPlaces", "Minimalna liczba miejsc (0 = bez limitu)", "int"],
+    ["Filter.MaxAmount", "Maksymalna cena w zł (0 = bez limitu)", "int"],
+    ["Filter.InfoContains", "Dodatkowe info musi zawierać", "text"],
+    ["Filter.InfoExcludes", "Dodatkowe info nie może zawierać", "text"],
+  ]],
+  ["Powiadomienia", [
+    ["Webhook.DiscordURL", "Discord webhook URL (puste = bez zmian)", "password"],
+    ["Webhook.QuietHours.Start", "Początek godzin ciszy (HH:MM)", "text"],
+    ["Webhook.QuietHours.End", "Koniec godzin ciszy (HH:MM)", "text"],
+    ["Webhook.DiscordErrorURL", "Discord webhook URL dla błędów (puste = bez zmian)", "password"],
+    ["Webhook.ErrorQuietHours.Start", "Początek godzin ciszy dla błędów", "text"],
+    ["Webhook.ErrorQuietHours.End", "Koniec godzin ciszy dla błędów", "text"],
+    ["Monitor.SuppressWindow", "Nie powiadamiaj ponownie przez (minuty)", "int"],
+    ["Monitor.DigestTime", "Godzina dziennego podsumowania (HH:MM)", "text"],
+    ["Monitor.FailureThreshold", "Alert po ilu kolejnych błędach", "int"],
+    ["Heartbeat.URL", "Heartbeat URL", "text"],
+  ]],
+  ["Zaawansowane", [
+    ["Monitor.Interval", "Interwał sprawdzania (sekundy)", "int"],
+    ["Catalog.RefreshHours", "Odświeżanie katalogu WORD (godziny)", "int"],
+    ["Monitor.Proxy", "Używać proxy", "bool"],
+    ["Monitor.ProxyAddress", "Adres proxy", "text"],
+    ["Monitor.Debug", "Debug", "bool"],
+    ["API.Password", "Hasło do panelu (puste = bez zmian)", "password"],
+  ]],
+];
+
+let config = null;
+let words = [];
+
+async function api(path, options) {
+  const res = await fetch(path, options);
+  const body = await res.json().catch(() => ({}));
+  if (!res.ok) {
+    throw body.errors || [res.statusText];
+  }
+  return body;
+}
+
+function get(obj, path) {
+  return path.split(".").reduce((o, k) => (o == null ? undefined : o[k]), obj);
+}
+
+function set(obj, path, value) {
+  const keys = path.split(".");
+  const last = keys.pop();
+  keys.reduce((o, k) => (o[k] = o[k] || {}), obj)[last] = value;
+}
+
+function el(tag, attrs, ...children) {
+  const e = document.createElement(tag);
+  Object.entries(attrs || {}).forEach(([k, v]) => {
+    if (k.startsWith("on")) e.addEventListener(k.slice(2), v);
+    else if (v !== false && v != null) e.setAttribute(k, v === true ? "" : v);
+  });
+  children.flat().forEach((c) => e.append(c instanceof Node ? c : document.createTextNode(c ?? "")));
+  return e;
+}
+
+function wordName(id) {
+  const w = words.find((w) => String(w.id) === String(id));
+  return w ? w.name : "WORD " + id;
+}
+
+// Tabs
+
+document.querySelectorAll("nav button").forEach((b) =>
+  b.addEventListener("click", () => {
+    document.querySelectorAll("nav button, .tab").forEach((e) => e.classList.remove("active"));
+    b.classList.add("active");
+    document.getElementById(b.dataset.tab).classList.add("active");
+    if (b.dataset.tab === "slots") loadSlots();
+  }),
+);
+
+// Slots
+
+const examTypes = { practice: "praktyczny", theory: "teoretyczny" };
+
+function slotRow(slot) {
+  const exams = slot.exams || [];
+  const types = exams.length
+    ? [...new Set(exams.map((e) => examTypes[e.type] || e.type))]
+    : [slot.practice_ids && slot.practice_ids.length ? "praktyczny" : null, slot.theory_ids && slot.theory_ids.length ? "teoretyczny" : null].filter(Boolean);
+  const prices = exams.map((e) => e.amount);
+  const places = exams.reduce((n, e) => n + e.places, 0);
+  const gone = !slot.gone_at.startsWith("0001");
+  return el("tr", { class: gone ? "gone" : false },
+    el("td", {}, slot.day),
+    el("td", {}, slot.time.slice(0, 5)),
+    el("td", {}, types.join(", ")),
+    el("td", {}, prices.length ? Math.min(...prices) + " zł" : "–"),
+    el("td", {}, exams.length ? String(places) : "–"),
+    el("td", {}, gone ? "zniknął " + new Date(slot.gone_at).toLocaleString("pl-PL") : "dostępny"),
+  );
+}
+
+async function loadSlots() {
+  const list = document.getElementById("slot-list");
+  const history = document.getElementById("show-history").checked;
+  try {
+    const targets = await api("api/slots");
+    list.replaceChildren(...targets.map((t) => {
+      const slots = t.slots
+        .filter((s) => history || s.gone_at.startsWith("0001"))
+        .sort((a, b) => (a.day + a.time).localeCompare(b.day + b.time));
+      return el("div", { class: "card" },
+        el("h3", {}, `${t.name} – kategoria ${t.target.Category}`),
+        el("div", { class: "address" }, t.address),
+        slots.length
+          ? el("table", {},
+              el("thead", {}, el("tr", {}, ["Dzień", "Godzina", "Egzamin", "Cena od", "Miejsca", "Stan"].map((h) => el("th", {}, h)))),
+              el("tbody", {}, slots.map(slotRow)))
+          : el("p", {}, "Brak wolnych terminów."),
+      );
+    }));
+    if (!targets.length) list.replaceChildren(el("p", {}, "Nie monitorujesz jeszcze żadnego WORDu. Wybierz go w zakładce „Wybór WORDu”."));
+  } catch (errs) {
+    list.replaceChildren(el("p", { class: "errors" }, [].concat(errs).join(", ")));
+  }
+}
+
+document.getElementById("show-history").addEventListener("change", loadSlots);
+
+// WORD picker
+
+function watched(id) {
+  return (config.Targets || []).some((t) => String(t.WordId) === String(id));
+}
+
+function addTarget(id) {
+  if (!watched(id)) {
+    config.Targets = config.Targets || [];
+    config.Targets.push({ WordId: String(id), Category: "B", MaxDays: 30 });
+  }
+  renderTargets();
+  renderMap();
+  document.querySelector('nav button[data-tab="config"]').click();
+}
+
+function renderWordList(list) {
+  document.getElementById("word-list").replaceChildren(...list.map((w) =>
+    el("li", {},
+      el("span", {}, w.name + (w.offline ? " (offline)" : ""), el("small", {}, `${w.address} · ${w.province}`)),
+      watched(w.id) ? el("span", {}, "monitorowany") : el("button", { type: "button", onclick: () => addTarget(w.id) }, "Dodaj"),
+    ),
+  ));
+}
+
+// renderMap plots the centers on a simple projection of Poland's bounding box.
+function renderMap() {
+  const svg = document.getElementById("map");
+  const ns = "http://www.w3.org/2000/svg";
+  svg.replaceChildren();
+  words.forEach((w) => {
+    const lat = parseFloat(w.latitude), lon = parseFloat(w.longitude);
+    if (isNaN(lat) || isNaN(lon)) return;
+    const c = document.createElementNS(ns, "circle");
+    c.setAttribute("cx", ((lon - 14) / 10.3) * 400);
+    c.setAttribute("cy", ((55 - lat) / 6.2) * 330);
+    c.setAttribute("r", 5);
+    c.setAttribute("class", watched(w.id) ? "watched" : w.offline ? "offline" : "");
+    const title = document.createElementNS(ns, "title");
+    title.textContent = `${w.name}\n${w.address}`;
+    c.append(title);
+    c.addEventListener("click", () => addTarget(w.id));
+    svg.append(c);
+  });
+}
+
+let searchTimer;
+document.getElementById("word-query").addEventListener("input", (e) => {
+  clearTimeout(searchTimer);
+  searchTimer = setTimeout(async () => {
+    const q = e.target.value.trim();
+    renderWordList(q ? await api("api/words?q=" + encodeURIComponent(q)) : words);
+  }, 200);
+});
+
+// Settings
+
+function renderTargets() {
+  const body = document.querySelector("#targets tbody");
+  body.replaceChildren(...(config.Targets || []).map((t, i) =>
+    el("tr", {},
+      el("td", {}, el("input", { type: "text", value: t.WordId, title: wordName(t.WordId), oninput: (e) => (t.WordId = e.target.value) }), " ", wordName(t.WordId)),
+      el("td", {}, el("input", { type: "text", value: t.Category, size: 4, oninput: (e) => (t.Category = e.target.value.toUpperCase()) })),
+      el("td", {}, el("input", { type: "number", min: 0, value: t.MaxDays, oninput: (e) => (t.MaxDays = parseInt(e.target.value, 10) || 0) })),
+      el("td", {}, el("button", { type: "button", onclick: () => { config.Targets.splice(i, 1); renderTargets(); } }, "Usuń")),
+    ),
+  ));
+}
+
+function renderFields() {
+  document.getElementById("fields").replaceChildren(...sections.map(([title, fields]) =>
+    el("fieldset", {}, el("legend", {}, title), fields.map(([path, label, type]) => {
+      const value = get(config, path);
+      const input = type === "bool"
+        ? el("input", { type: "checkbox", checked: !!value, onchange: (e) => set(config, path, e.target.checked) })
+        : el("input", {
+            type: type === "int" ? "number" : type,
+            value: value ?? "",
+            oninput: (e) => set(config, path, type === "int" ? parseInt(e.target.value, 10) || 0 : e.target.value),
+          });
+      return el("label", {}, el("span", {}, label), input);
+    })),
+  ));
+}
+
+document.getElementById("add-target").addEventListener("click", () => {
+  config.Targets = config.Targets || [];
+  config.Targets.push({ WordId: "", Category: "B", MaxDays: 30 });
+  renderTargets();
+});
+
+document.getElementById("config-form").addEventListener("submit", async (e) => {
+  e.preventDefault();
+  const errors = document.getElementById("errors");
+  const saved = document.getElementById("saved");
+  errors.replaceChildren();
+  saved.textContent = "";
+  try {
+    config = await api("api/config", { method: "PUT", headers: { "Content-Type": "application/json" }, body: JSON.stringify(config) });
+    saved.textContent = "Zapisano.";
+    renderTargets();
+    renderFields();
+    renderMap();
+  } catch (errs) {
+    errors.replaceChildren(el("ul", {}, [].concat(errs).map((m) => el("li", {}, m))));
+  }
+});
+
+async function init() {
+  [config, words] = await Promise.all([api("api/config"), api("api/words")]);
+  // Older configs watch a single WORD; the form edits the targets list.
+  if (!(config.Targets || []).length && config.Word && config.Word.WordId) {
+    config.Targets = [config.Word];
+    config.Word = { WordId: "", Category: "", MaxDays: 0 };
+  }
+  renderTargets();
+  renderFields();
+  renderMap();
+  renderWordList(words);
+  loadSlots();
+}
+
+init();
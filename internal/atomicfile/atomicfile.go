@@ -0,0 +1,79 @@
+// Package atomicfile replaces files so that a crash leaves either the old or
+// the new content on disk, never a partial write.
+package atomicfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Write stores data in a temporary file next to path, syncs it and renames it
+// over path. When backups is positive the previous file is kept as path.bak.1,
+// shifting older copies up to path.bak.<backups>.
+func Write(path string, data []byte, perm os.FileMode, backups int) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if backups > 0 {
+		if err := rotate(path, backups); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// Backup returns the name of the n-th backup of path, 1 being the newest.
+func Backup(path string, n int) string {
+	return fmt.Sprintf("%s.bak.%d", path, n)
+}
+
+// rotate shifts the backups and moves the current file to the first one.
+// Until the new file is renamed into place only the backup exists, which
+// readers are expected to fall back to.
+func rotate(path string, backups int) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	for n := backups - 1; n >= 1; n-- {
+		if err := os.Rename(Backup(path, n), Backup(path, n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(path, Backup(path, 1))
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	// Some filesystems do not support syncing directories; the rename itself
+	// already happened.
+	_ = d.Sync()
+	return nil
+}
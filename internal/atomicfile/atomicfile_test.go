@@ -0,0 +1,63 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteRotatesBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	for _, content := range []string{"1", "2", "3", "4"} {
+		if err := Write(path, []byte(content), 0644, 2); err != nil {
+			t.Fatalf("Write(%s): %v", content, err)
+		}
+	}
+
+	want := map[string]string{path: "4", Backup(path, 1): "3", Backup(path, 2): "2"}
+	for name, content := range want {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("ReadFile(%s): %v", name, err)
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q, want %q", filepath.Base(name), data, content)
+		}
+	}
+	if _, err := os.Stat(Backup(path, 3)); !os.IsNotExist(err) {
+		t.Errorf("Stat(.bak.3) error = %v, want the oldest copy dropped", err)
+	}
+}
+
+func TestWriteLeavesNoTemporaryFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	if err := Write(path, []byte("{}"), 0600, 0); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "state.json" {
+		t.Errorf("directory holds %v, want only state.json", entries)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestWriteWithoutPreviousFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	if err := Write(path, []byte("{}"), 0644, 3); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if _, err := os.Stat(Backup(path, 1)); !os.IsNotExist(err) {
+		t.Errorf("Stat(.bak.1) error = %v, want no backup of a new file", err)
+	}
+}
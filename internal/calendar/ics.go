@@ -0,0 +1,180 @@
+// Package calendar exports the currently available exam slots as an
+// iCalendar feed.
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/atomicfile"
+	"github.com/kapi1023/word-monitor/internal/catalog"
+	"github.com/kapi1023/word-monitor/internal/config"
+	"github.com/kapi1023/word-monitor/internal/source"
+	"github.com/kapi1023/word-monitor/internal/state"
+)
+
+const (
+	ContentType = "text/calendar; charset=utf-8"
+
+	examDuration = time.Hour
+	stampLayout  = "20060102T150405Z"
+	maxLineLen   = 75
+)
+
+type event struct {
+	uid      string
+	start    time.Time
+	summary  string
+	location string
+	details  string
+}
+
+// Build renders one event per available exam of every target. Slots stored
+// before exams were recorded individually become a single event.
+func Build(targets []config.WORD, storage state.Store, words *catalog.Catalog, now time.Time) ([]byte, error) {
+	var events []event
+	for _, t := range targets {
+		key := state.Key(t.WordId, t.Category)
+		name, address := "WORD "+t.WordId, ""
+		if id, err := strconv.Atoi(t.WordId); err == nil {
+			if word, ok := words.ByID(id); ok {
+				name, address = word.Name, word.Address
+			}
+		}
+		slots, err := storage.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		for _, slot := range slots {
+			if !slot.Active() {
+				continue
+			}
+			for _, e := range slotEvents(slot) {
+				e.uid = fmt.Sprintf("%s-%s@word-monitor", key, e.uid)
+				e.summary = fmt.Sprintf("%s kat. %s – %s", e.summary, t.Category, name)
+				e.location = address
+				events = append(events, e)
+			}
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].start.Equal(events[j].start) {
+			return events[i].start.Before(events[j].start)
+		}
+		return events[i].uid < events[j].uid
+	})
+
+	var b strings.Builder
+	line := func(format string, args ...any) {
+		writeFolded(&b, fmt.Sprintf(format, args...))
+	}
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//word-monitor//wolne terminy//PL")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:%s", escape("Wolne terminy WORD"))
+	stamp := now.UTC().Format(stampLayout)
+	for _, e := range events {
+		line("BEGIN:VEVENT")
+		line("UID:%s", e.uid)
+		line("DTSTAMP:%s", stamp)
+		line("DTSTART:%s", e.start.UTC().Format(stampLayout))
+		line("DTEND:%s", e.start.Add(examDuration).UTC().Format(stampLayout))
+		line("SUMMARY:%s", escape(e.summary))
+		if e.location != "" {
+			line("LOCATION:%s", escape(e.location))
+		}
+		line("DESCRIPTION:%s", escape(e.details))
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return []byte(b.String()), nil
+}
+
+func slotEvents(slot state.ExamSlot) []event {
+	var events []event
+	for _, e := range slot.Exams {
+		start, err := source.Exam{Date: e.Date}.Time()
+		if err != nil {
+			continue
+		}
+		details := fmt.Sprintf("Egzamin %s\nCena: %d zł\nMiejsca: %d", typeName(e.Type), e.Amount, e.Places)
+		if e.Info != "" {
+			details += "\n" + e.Info
+		}
+		events = append(events, event{
+			uid:     e.Type + "-" + e.ID,
+			start:   start,
+			summary: "Egzamin " + typeName(e.Type),
+			details: details,
+		})
+	}
+	if len(events) > 0 {
+		return events
+	}
+
+	start, err := time.ParseInLocation("2006-01-02 15:04:05", slot.Day+" "+slot.Time, source.Location)
+	if err != nil {
+		start, err = time.ParseInLocation("2006-01-02 15:04", slot.Day+" "+slot.Time, source.Location)
+		if err != nil {
+			return nil
+		}
+	}
+	var types []string
+	if len(slot.PracticeIDs) > 0 {
+		types = append(types, typeName(source.ExamPractice))
+	}
+	if len(slot.TheoryIDs) > 0 {
+		types = append(types, typeName(source.ExamTheory))
+	}
+	summary := "Egzamin " + strings.Join(types, " i ")
+	return []event{{uid: state.SlotID(slot.Day, slot.Time), start: start, summary: summary, details: summary}}
+}
+
+func typeName(examType string) string {
+	switch examType {
+	case source.ExamPractice:
+		return "praktyczny"
+	case source.ExamTheory:
+		return "teoretyczny"
+	}
+	return examType
+}
+
+// WriteFile replaces the feed atomically, so calendar clients reading the
+// file never see a partial one.
+func WriteFile(path string, data []byte) error {
+	return atomicfile.Write(path, data, 0644, 0)
+}
+
+// escape quotes text values as required by RFC 5545.
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// writeFolded ends the content line with CRLF, folding it into 75-octet
+// chunks without splitting UTF-8 sequences.
+func writeFolded(b *strings.Builder, s string) {
+	limit := maxLineLen
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		limit = maxLineLen - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
+
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
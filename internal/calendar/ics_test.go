@@ -0,0 +1,124 @@
+package calendar
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kapi1023/word-monitor/internal/catalog"
+	"github.com/kapi1023/word-monitor/internal/config"
+	"github.com/kapi1023/word-monitor/internal/infocar"
+	"github.com/kapi1023/word-monitor/internal/state"
+)
+
+func TestEscape(t *testing.T) {
+	got := escape("ul. Odlewnicza 8, Warszawa; wjazd\\brama\nparter\r\nokienko 2")
+	want := `ul. Odlewnicza 8\, Warszawa\; wjazd\\brama\nparter\nokienko 2`
+	if got != want {
+		t.Errorf("escape = %q, want %q", got, want)
+	}
+}
+
+func TestWriteFolded(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"short", "SUMMARY:Egzamin"},
+		{"exactly 75 octets", "DESCRIPTION:" + strings.Repeat("a", 63)},
+		{"ascii", "DESCRIPTION:" + strings.Repeat("abcdefghij", 20)},
+		{"polish", "SUMMARY:" + strings.Repeat("Egzamin praktyczny – Łódź, Żółkiewskiego ", 5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			writeFolded(&b, tt.line)
+			out := b.String()
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("line %q does not end with CRLF", out)
+			}
+			lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			var unfolded strings.Builder
+			for i, l := range lines {
+				if len(l) > maxLineLen {
+					t.Errorf("line %d has %d octets, want at most %d", i, len(l), maxLineLen)
+				}
+				if !utf8.ValidString(l) {
+					t.Errorf("line %d splits a UTF-8 sequence: %q", i, l)
+				}
+				if i > 0 {
+					if !strings.HasPrefix(l, " ") {
+						t.Errorf("continuation line %d does not start with a space: %q", i, l)
+					}
+					l = l[1:]
+				}
+				unfolded.WriteString(l)
+			}
+			if unfolded.String() != tt.line {
+				t.Errorf("unfolded = %q, want %q", unfolded.String(), tt.line)
+			}
+			if len(tt.line) <= maxLineLen && len(lines) != 1 {
+				t.Errorf("a %d octet line was folded", len(tt.line))
+			}
+		})
+	}
+}
+
+func TestBuildUsesPolishTime(t *testing.T) {
+	// The result must not depend on the zone of the machine.
+	prev := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = prev })
+
+	dir := t.TempDir()
+	snap, err := json.Marshal(catalog.Snapshot{Words: []infocar.Word{{ID: 1, Name: "WORD Łódź", Address: "ul. Nowy Józefów 70, Łódź"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "catalog.json"), snap, 0644); err != nil {
+		t.Fatal(err)
+	}
+	words := catalog.New(filepath.Join(dir, "catalog.json"), nil)
+	storage, err := state.NewFile(filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := state.Key("1", "B")
+	slots := []state.ExamSlot{
+		// Summer time, UTC+2.
+		{Day: "2030-07-01", Time: "08:00:00", Exams: []state.Exam{{ID: "p1", Type: "practice", Date: "2030-07-01T08:00:00", Places: 1, Amount: 200}}},
+		// Winter time, UTC+1, a slot stored before exams were kept.
+		{Day: "2030-01-02", Time: "09:30:00", TheoryIDs: []string{"t1"}},
+	}
+	if err := storage.Put(key, slots); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	data, err := Build([]config.WORD{{WordId: "1", Category: "B"}}, storage, words, now)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	ics := strings.ReplaceAll(string(data), "\r\n ", "")
+	for _, want := range []string{
+		"DTSTAMP:20300101T120000Z",
+		"DTSTART:20300102T083000Z",
+		"DTEND:20300102T093000Z",
+		"DTSTART:20300701T060000Z",
+		"DTEND:20300701T070000Z",
+		`LOCATION:ul. Nowy Józefów 70\, Łódź`,
+		"SUMMARY:Egzamin praktyczny kat. B – WORD Łódź",
+		`DESCRIPTION:Egzamin praktyczny\nCena: 200 zł\nMiejsca: 1`,
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("feed lacks %q:\n%s", want, ics)
+		}
+	}
+	if strings.Index(ics, "20300102T083000Z") > strings.Index(ics, "20300701T060000Z") {
+		t.Error("events are not sorted by start")
+	}
+}
@@ -0,0 +1,271 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/atomicfile"
+	"github.com/kapi1023/word-monitor/internal/infocar"
+)
+
+var ErrNotFound = errors.New("catalog: not found")
+
+// Snapshot is the word-centers list as persisted to disk.
+type Snapshot struct {
+	FetchedAt time.Time           `json:"fetched_at"`
+	ETag      string              `json:"etag"`
+	Provinces []infocar.Provinces `json:"provinces"`
+	Words     []infocar.Word      `json:"words"`
+}
+
+// Catalog keeps the word-centers list in memory and on disk, so lookups keep
+// working from the last snapshot when info-car is unreachable.
+type Catalog struct {
+	path   string
+	client *infocar.InfocarClient
+
+	// refreshing serialises refreshes started by Run, the poll cycle and the
+	// menu, so only one downloads the list and writes the file at a time.
+	refreshing sync.Mutex
+
+	mu   sync.RWMutex
+	snap Snapshot
+	byID map[int]infocar.Word
+}
+
+func New(path string, client *infocar.InfocarClient) *Catalog {
+	abs, _ := filepath.Abs(path)
+	c := &Catalog{
+		path:   abs,
+		client: client,
+		byID:   make(map[int]infocar.Word),
+	}
+	if err := c.load(); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Nie udało się wczytać katalogu WORD z dysku", "path", abs, "err", err)
+	}
+	return c
+}
+
+func (c *Catalog) load() error {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+	c.set(snap)
+	return nil
+}
+
+func (c *Catalog) save() error {
+	c.mu.RLock()
+	data, err := json.MarshalIndent(c.snap, "", "  ")
+	c.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(c.path, data, 0644, 0)
+}
+
+func (c *Catalog) set(snap Snapshot) {
+	byID := make(map[int]infocar.Word, len(snap.Words))
+	for _, w := range snap.Words {
+		byID[w.ID] = w
+	}
+	c.mu.Lock()
+	c.snap = snap
+	c.byID = byID
+	c.mu.Unlock()
+}
+
+// Refresh downloads the list unless info-car reports that the stored ETag is
+// still current, and persists the result.
+func (c *Catalog) Refresh(ctx context.Context) error {
+	c.refreshing.Lock()
+	defer c.refreshing.Unlock()
+	return c.refresh(ctx)
+}
+
+func (c *Catalog) refresh(ctx context.Context) error {
+	c.mu.RLock()
+	etag := c.snap.ETag
+	if len(c.snap.Words) == 0 {
+		etag = ""
+	}
+	c.mu.RUnlock()
+
+	words, etag, err := c.client.FetchWordCenters(ctx, etag)
+	if err != nil {
+		return err
+	}
+	if words == nil {
+		c.mu.Lock()
+		c.snap.FetchedAt = time.Now()
+		c.mu.Unlock()
+		slog.DebugContext(ctx, "Katalog WORD bez zmian")
+	} else {
+		c.set(Snapshot{
+			FetchedAt: time.Now(),
+			ETag:      etag,
+			Provinces: words.Provinces,
+			Words:     words.Words,
+		})
+		slog.DebugContext(ctx, "Pobrano katalog WORD", "words", len(words.Words))
+	}
+	return c.save()
+}
+
+// EnsureFresh refreshes the catalog when it is empty or older than maxAge. A
+// failed refresh is not an error as long as an older snapshot is available.
+func (c *Catalog) EnsureFresh(ctx context.Context, maxAge time.Duration) error {
+	c.refreshing.Lock()
+	defer c.refreshing.Unlock()
+	// Checked under the lock, so callers waiting for a refresh in progress
+	// use its result instead of downloading again.
+	c.mu.RLock()
+	fetchedAt, empty := c.snap.FetchedAt, len(c.snap.Words) == 0
+	c.mu.RUnlock()
+	if !empty && time.Since(fetchedAt) < maxAge {
+		return nil
+	}
+	err := c.refresh(ctx)
+	if err != nil && !empty {
+		slog.WarnContext(ctx, "Nie udało się odświeżyć katalogu WORD, używam zapisanego", "fetched_at", fetchedAt, "err", err)
+		return nil
+	}
+	return err
+}
+
+// Run refreshes the catalog every interval until stop is closed.
+func (c *Catalog) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := c.Refresh(context.Background()); err != nil {
+				slog.Warn("Nie udało się odświeżyć katalogu WORD", "err", err)
+			}
+		}
+	}
+}
+
+func (c *Catalog) FetchedAt() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.snap.FetchedAt
+}
+
+func (c *Catalog) Words() []infocar.Word {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]infocar.Word(nil), c.snap.Words...)
+}
+
+func (c *Catalog) Provinces() []infocar.Provinces {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]infocar.Provinces(nil), c.snap.Provinces...)
+}
+
+func (c *Catalog) ByID(id int) (infocar.Word, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	w, ok := c.byID[id]
+	return w, ok
+}
+
+// ByName returns the centers whose name contains name, ignoring case and
+// diacritics.
+func (c *Catalog) ByName(name string) []infocar.Word {
+	name = Fold(name)
+	var words []infocar.Word
+	for _, w := range c.Words() {
+		if strings.Contains(Fold(w.Name), name) {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+func (c *Catalog) ProvinceName(id int) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, p := range c.snap.Provinces {
+		if p.ID == id {
+			return p.Name
+		}
+	}
+	return ""
+}
+
+// ByProvince returns the centers of the first province whose name contains
+// provinceName, ignoring case and diacritics.
+func (c *Catalog) ByProvince(provinceName string) ([]infocar.Word, error) {
+	provinceName = Fold(provinceName)
+	var provinceID int
+	for _, p := range c.Provinces() {
+		if strings.Contains(Fold(p.Name), provinceName) {
+			provinceID = p.ID
+			break
+		}
+	}
+	if provinceID == 0 {
+		return nil, ErrNotFound
+	}
+
+	var words []infocar.Word
+	for _, w := range c.Words() {
+		if w.ProvinceID == provinceID {
+			words = append(words, w)
+		}
+	}
+	return words, nil
+}
+
+// Nearest returns up to n centers ordered by distance from the given point.
+// Centers without valid coordinates are skipped.
+func (c *Catalog) Nearest(lat, lon float64, n int) []infocar.Word {
+	type located struct {
+		word infocar.Word
+		km   float64
+	}
+	var all []located
+	for _, w := range c.Words() {
+		wLat, err1 := strconv.ParseFloat(w.Latitude, 64)
+		wLon, err2 := strconv.ParseFloat(w.Longitude, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		all = append(all, located{w, distanceKm(lat, lon, wLat, wLon)})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].km < all[j].km })
+
+	var words []infocar.Word
+	for i := 0; i < len(all) && i < n; i++ {
+		words = append(words, all[i].word)
+	}
+	return words
+}
+
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat, dLon := rad(lat2-lat1), rad(lon2-lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(rad(lat1))*math.Cos(rad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
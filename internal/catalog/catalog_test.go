@@ -0,0 +1,59 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/config"
+	"github.com/kapi1023/word-monitor/internal/infocar"
+)
+
+func TestEnsureFreshDownloadsOnce(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != config.PathWords {
+			http.NotFound(w, r)
+			return
+		}
+		requests.Add(1)
+		// Slow enough for the callers to overlap.
+		time.Sleep(50 * time.Millisecond)
+		w.Header().Set("ETag", `"v1"`)
+		json.NewEncoder(w).Encode(infocar.AvailableWords{
+			Provinces: []infocar.Provinces{{ID: 7, Name: "mazowieckie"}},
+			Words:     []infocar.Word{{ID: 1, Name: "WORD Warszawa", ProvinceID: 7}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	c := New(path, infocar.NewCLient(srv.URL, srv.Client()))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.EnsureFresh(context.Background(), time.Hour); err != nil {
+				t.Errorf("EnsureFresh: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("word-centers requested %d times, want 1", got)
+	}
+	if _, ok := c.ByID(1); !ok {
+		t.Error("catalog lacks the downloaded center")
+	}
+	if saved := New(path, nil); len(saved.Words()) != 1 {
+		t.Errorf("saved catalog holds %d centers, want 1", len(saved.Words()))
+	}
+}
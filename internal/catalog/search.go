@@ -0,0 +1,187 @@
+package catalog
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/kapi1023/word-monitor/internal/config"
+	"github.com/kapi1023/word-monitor/internal/infocar"
+)
+
+// Field weights: a hit in the center name counts more than one in the
+// province or the street address.
+const (
+	weightName     = 1.0
+	weightProvince = 0.8
+	weightAddress  = 0.6
+
+	minSimilarity = 0.7
+	// A resolved match must be at least this good and ahead of the runner-up.
+	minResolveScore = 0.75
+	resolveMargin   = 0.1
+)
+
+var polishFolds = map[rune]rune{
+	'ą': 'a', 'ć': 'c', 'ę': 'e', 'ł': 'l', 'ń': 'n', 'ó': 'o', 'ś': 's', 'ź': 'z', 'ż': 'z',
+}
+
+type Match struct {
+	Word     infocar.Word
+	Province string
+	Score    float64
+}
+
+// Fold lowercases s and strips Polish diacritics, so "Śląskie" and "slaskie"
+// compare equal.
+func Fold(s string) string {
+	return strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if f, ok := polishFolds[r]; ok {
+			return f
+		}
+		return r
+	}, s)
+}
+
+func tokens(s string) []string {
+	return strings.FieldsFunc(Fold(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Search ranks centers by how well query matches their name, province and
+// address. Every query word has to match at least one field, possibly with a
+// typo. At most limit results are returned, all of them when limit <= 0.
+func (c *Catalog) Search(query string, limit int) []Match {
+	queryTokens := tokens(query)
+	if len(queryTokens) == 0 {
+		return nil
+	}
+
+	var matches []Match
+	for _, w := range c.Words() {
+		province := c.ProvinceName(w.ProvinceID)
+		fields := []struct {
+			tokens []string
+			weight float64
+		}{
+			{tokens(w.Name), weightName},
+			{tokens(province), weightProvince},
+			{tokens(w.Address), weightAddress},
+		}
+
+		var total float64
+		for _, qt := range queryTokens {
+			var best float64
+			for _, f := range fields {
+				best = max(best, tokenScore(qt, f.tokens)*f.weight)
+			}
+			if best == 0 {
+				total = 0
+				break
+			}
+			total += best
+		}
+		if total > 0 {
+			matches = append(matches, Match{Word: w, Province: province, Score: total / float64(len(queryTokens))})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Word.Name < matches[j].Word.Name
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// Resolve turns a WORD ID or a free-text query into a single center. When the
+// query is ambiguous the error lists the best candidates.
+func (c *Catalog) Resolve(query string) (infocar.Word, error) {
+	if id, err := strconv.Atoi(strings.TrimSpace(query)); err == nil {
+		if w, ok := c.ByID(id); ok {
+			return w, nil
+		}
+		return infocar.Word{}, fmt.Errorf("WORD o ID %d nie istnieje w katalogu", id)
+	}
+
+	matches := c.Search(query, 5)
+	if len(matches) == 0 {
+		return infocar.Word{}, fmt.Errorf("nie znaleziono WORDu dla %q", query)
+	}
+	if matches[0].Score >= minResolveScore && (len(matches) == 1 || matches[0].Score-matches[1].Score >= resolveMargin) {
+		return matches[0].Word, nil
+	}
+
+	var candidates []string
+	for _, m := range matches {
+		candidates = append(candidates, fmt.Sprintf("%d %s", m.Word.ID, m.Word.Name))
+	}
+	return infocar.Word{}, fmt.Errorf("brak jednoznacznego dopasowania dla %q, propozycje: %s", query, strings.Join(candidates, "; "))
+}
+
+// ValidateTargets checks every target's WORD ID against the catalog. Targets
+// given by name are rewritten to the ID of the single matching center.
+func (c *Catalog) ValidateTargets(targets []config.WORD) []error {
+	var errs []error
+	for i, t := range targets {
+		w, err := c.Resolve(t.WordId)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		targets[i].WordId = strconv.Itoa(w.ID)
+	}
+	return errs
+}
+
+func tokenScore(query string, fieldTokens []string) float64 {
+	var best float64
+	for _, ft := range fieldTokens {
+		switch {
+		case ft == query:
+			return 1
+		case strings.HasPrefix(ft, query):
+			best = max(best, 0.9)
+		case strings.Contains(ft, query):
+			best = max(best, 0.8)
+		default:
+			if sim := similarity(query, ft); sim >= minSimilarity {
+				best = max(best, sim*0.7)
+			}
+		}
+	}
+	return best
+}
+
+// similarity is 1 minus the Levenshtein distance scaled by the longer length.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(max(len(ra), len(rb)))
+}
@@ -0,0 +1,142 @@
+package catalog
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/kapi1023/word-monitor/internal/infocar"
+)
+
+func testCatalog() *Catalog {
+	c := &Catalog{byID: make(map[int]infocar.Word)}
+	c.set(Snapshot{
+		Provinces: []infocar.Provinces{
+			{ID: 7, Name: "mazowieckie"},
+			{ID: 6, Name: "małopolskie"},
+			{ID: 5, Name: "łódzkie"},
+			{ID: 12, Name: "śląskie"},
+			{ID: 11, Name: "pomorskie"},
+		},
+		Words: []infocar.Word{
+			{ID: 1, Name: "WORD Warszawa", Address: "ul. Odlewnicza 8, Warszawa", ProvinceID: 7},
+			{ID: 2, Name: "WORD Kraków", Address: "ul. Nowohucka 33a, Kraków", ProvinceID: 6},
+			{ID: 3, Name: "WORD Łódź", Address: "ul. Nowy Józefów 70, Łódź", ProvinceID: 5},
+			{ID: 4, Name: "WORD Katowice", Address: "ul. Francuska 78, Katowice", ProvinceID: 12},
+			{ID: 5, Name: "WORD Katowice Oddział Bielsko-Biała", Address: "ul. Bestwińska 105, Bielsko-Biała", ProvinceID: 12},
+			{ID: 6, Name: "WORD Gdańsk", Address: "ul. Równa 19/21, Gdańsk", ProvinceID: 11},
+			{ID: 7, Name: "WORD Słupsk", Address: "ul. Mierosławskiego 10, Słupsk", ProvinceID: 11},
+		},
+	})
+	return c
+}
+
+func TestFold(t *testing.T) {
+	if got := Fold("Śląskie ŻÓŁĆ Gdańsk"); got != "slaskie zolc gdansk" {
+		t.Errorf("Fold = %q", got)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"krakow", "krakow", 1},
+		{"krakw", "krakow", 1 - 1.0/6},
+		{"katowice", "katowcie", 1 - 2.0/8},
+		{"lodz", "gdansk", 0},
+		{"", "lodz", 0},
+	}
+	for _, tt := range tests {
+		if got := similarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("similarity(%q, %q) = %.3f, want %.3f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	c := testCatalog()
+	tests := []struct {
+		name  string
+		query string
+		want  int
+		// errParts must appear in the error when want is 0.
+		errParts []string
+	}{
+		{name: "id", query: " 3 ", want: 3},
+		{name: "unknown id", query: "99", errParts: []string{"ID 99"}},
+		{name: "diacritics stripped", query: "lodz", want: 3},
+		{name: "diacritics kept", query: "ŁÓDŹ", want: 3},
+		{name: "folded ł", query: "slupsk", want: 7},
+		{name: "center name", query: "Kraków", want: 2},
+		{name: "branch by city", query: "Bielsko-Biała", want: 5},
+		{name: "city and street", query: "katowice francuska", want: 4},
+		{name: "name shared with a branch", query: "Katowice", errParts: []string{"brak jednoznacznego", "4 WORD Katowice", "5 WORD Katowice Oddział Bielsko-Biała"}},
+		{name: "province", query: "pomorskie", errParts: []string{"6 WORD Gdańsk", "7 WORD Słupsk"}},
+		{name: "street only", query: "Mierosławskiego", errParts: []string{"7 WORD Słupsk"}},
+		{name: "typo is not enough", query: "Krakw", errParts: []string{"2 WORD Kraków"}},
+		{name: "no match", query: "Szczecin", errParts: []string{"nie znaleziono"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := c.Resolve(tt.query)
+			if tt.want != 0 {
+				if err != nil || w.ID != tt.want {
+					t.Fatalf("Resolve(%q) = %d, %v; want %d", tt.query, w.ID, err, tt.want)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Resolve(%q) = %d, want an error", tt.query, w.ID)
+			}
+			for _, part := range tt.errParts {
+				if !strings.Contains(err.Error(), part) {
+					t.Errorf("error %q lacks %q", err, part)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	c := testCatalog()
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		// A typo still finds the center.
+		{"Krakw", []int{2}},
+		// A prefix of the province finds both centers, ordered by name.
+		{"śląsk", []int{4, 5}},
+		// A name hit ranks above an address hit.
+		{"Bielsko", []int{5}},
+		// Every word has to match something.
+		{"Warszawa Gdańsk", nil},
+		{"  ", nil},
+	}
+	for _, tt := range tests {
+		var got []int
+		for _, m := range c.Search(tt.query, 0) {
+			got = append(got, m.Word.ID)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+				break
+			}
+		}
+	}
+
+	if got := c.Search("WORD", 3); len(got) != 3 {
+		t.Errorf("Search with limit 3 returned %d matches", len(got))
+	}
+	matches := c.Search("Warszawa", 0)
+	if len(matches) != 1 || matches[0].Province != "mazowieckie" || matches[0].Score != 1 {
+		t.Errorf("Search(Warszawa) = %+v, want WORD Warszawa in mazowieckie with score 1", matches)
+	}
+}
@@ -2,10 +2,15 @@ package config
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -20,8 +25,20 @@ type Credential struct {
 }
 
 type Webhook struct {
-	DiscordURL            string `yaml:"discord_url"`
-	DiscordHealthCheckUrl string `yaml:"discord_health_check_url"`
+	DiscordURL      string     `yaml:"discord_url"`
+	QuietHours      QuietHours `yaml:"quiet_hours"`
+	DiscordErrorURL string     `yaml:"discord_error_url"`
+	ErrorQuietHours QuietHours `yaml:"error_quiet_hours"`
+}
+
+type Heartbeat struct {
+	URL string `yaml:"url"`
+}
+
+// QuietHours is a daily "HH:MM" range, which may wrap past midnight.
+type QuietHours struct {
+	Start string `yaml:"start"`
+	End   string `yaml:"end"`
 }
 
 type WORD struct {
@@ -31,36 +48,503 @@ type WORD struct {
 }
 
 type Monitor struct {
-	UrlLogin            string `yaml:"url_login"`
-	UrlCheck            string `yaml:"url_check"`
-	Interval            int    `yaml:"interval"`
-	HealthCheckInterval int    `yaml:"health_check_interval"`
-	Proxy               bool   `yaml:"proxy"`
-	ProxyAddress        string `yaml:"proxy_address"`
-	Debug               bool   `yaml:"debug"`
-	PracticeExams       bool   `yaml:"practice_exams"`
-	TheoryExams         bool   `yaml:"theory_exams"`
+	BaseUrl          string `yaml:"base_url"`
+	Interval         int    `yaml:"interval"`
+	Proxy            bool   `yaml:"proxy"`
+	ProxyAddress     string `yaml:"proxy_address"`
+	Debug            bool   `yaml:"debug"`
+	PracticeExams    bool   `yaml:"practice_exams"`
+	TheoryExams      bool   `yaml:"theory_exams"`
+	SuppressWindow   int    `yaml:"suppress_window"`
+	DigestTime       string `yaml:"digest_time"`
+	FailureThreshold int    `yaml:"failure_threshold"`
+	RecordDir        string `yaml:"record_dir"`
+	ReplayDir        string `yaml:"replay_dir"`
+	DriftDir         string `yaml:"drift_dir"`
+}
+
+// Filter limits which exams trigger notifications. Zero values disable a rule.
+type Filter struct {
+	MinPlaces    int    `yaml:"min_places"`
+	MaxAmount    int    `yaml:"max_amount"`
+	InfoContains string `yaml:"info_contains"`
+	InfoExcludes string `yaml:"info_excludes"`
+}
+
+type Catalog struct {
+	RefreshHours int `yaml:"refresh_hours"`
+}
+
+// Leader enables leader election between redundant instances: "file" locks
+// LockFile on one host, "redis" keeps a lease in the state.redis server. Only
+// the leader polls and notifies.
+type Leader struct {
+	Mode         string `yaml:"mode"`
+	LockFile     string `yaml:"lock_file"`
+	LeaseSeconds int    `yaml:"lease_seconds"`
+	ID           string `yaml:"id"`
+}
+
+// API configures the local HTTP server, e.g. ":2115". Empty disables it.
+// When Password is set the server requires HTTP basic auth; it is mandatory
+// for an address reachable from other machines.
+type API struct {
+	Address  string `yaml:"address"`
+	Password string `yaml:"password"`
+}
+
+// ListenAddress is Address with a missing host replaced by 127.0.0.1, so
+// ":2115" only accepts connections from this machine.
+func (a API) ListenAddress() string {
+	host, port, err := net.SplitHostPort(a.Address)
+	if err != nil || host != "" {
+		return a.Address
+	}
+	return net.JoinHostPort("127.0.0.1", port)
+}
+
+// Public reports whether the server listens beyond the loopback interface.
+func (a API) Public() bool {
+	host, _, err := net.SplitHostPort(a.ListenAddress())
+	if err != nil {
+		return true
+	}
+	if host == "localhost" {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip == nil || !ip.IsLoopback()
+}
+
+// HTTP tunes the client used for info-car and webhook traffic. Durations are
+// in seconds; zero values keep the defaults. UserAgents are rotated on every
+// info-car login.
+type HTTP struct {
+	ConnectTimeout int      `yaml:"connect_timeout"`
+	ReadTimeout    int      `yaml:"read_timeout"`
+	Timeout        int      `yaml:"timeout"`
+	KeepAlive      int      `yaml:"keep_alive"`
+	IdleTimeout    int      `yaml:"idle_timeout"`
+	MaxIdleConns   int      `yaml:"max_idle_conns"`
+	CABundle       string   `yaml:"ca_bundle"`
+	UserAgents     []string `yaml:"user_agents"`
+	AcceptLanguage string   `yaml:"accept_language"`
+}
+
+// Log selects the log output: Format is "text" (default) or "json", Level
+// one of debug, info, warn, error. Empty Level follows Monitor.Debug.
+type Log struct {
+	Format string `yaml:"format"`
+	Level  string `yaml:"level"`
 }
 
+// Tracing enables OpenTelemetry spans: Exporter is "otlp" (OTLP over HTTP to
+// Endpoint, e.g. "localhost:4318") or "stdout". Empty disables tracing.
+type Tracing struct {
+	Exporter    string `yaml:"exporter"`
+	Endpoint    string `yaml:"endpoint"`
+	Insecure    bool   `yaml:"insecure"`
+	ServiceName string `yaml:"service_name"`
+}
+
+// Calendar configures the iCalendar feed of available slots.
+type Calendar struct {
+	File string `yaml:"file"`
+}
+
+// State selects where known slots are kept: "file" (default), "bolt" for an
+// embedded database or "redis" to share them between instances.
 type State struct {
-	SecretKey string `yaml:"secret_key"`
+	SecretKey string     `yaml:"secret_key"`
+	Backend   string     `yaml:"backend"`
+	Redis     RedisState `yaml:"redis"`
+}
+
+type RedisState struct {
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+	Prefix   string `yaml:"prefix"`
 }
 type Config struct {
 	Credential Credential `yaml:"credential"`
 	Webhook    Webhook    `yaml:"webhook"`
+	Heartbeat  Heartbeat  `yaml:"heartbeat"`
 	Monitor    Monitor    `yaml:"monitor"`
 	Word       WORD       `yaml:"word"`
+	Targets    []WORD     `yaml:"targets"`
+	Catalog    Catalog    `yaml:"catalog"`
+	Filter     Filter     `yaml:"filter"`
 	State      State      `yaml:"state"`
+	Leader     Leader     `yaml:"leader"`
+	API        API        `yaml:"api"`
+	Calendar   Calendar   `yaml:"calendar"`
+	Log        Log        `yaml:"log"`
+	Tracing    Tracing    `yaml:"tracing"`
+	HTTP       HTTP       `yaml:"http"`
+
+	// refs remembers secrets read from the vault, by field, so Save writes
+	// the references back instead of the values.
+	refs map[string]secretRef
+}
+
+// VaultPrefix marks a secret stored in the vault, e.g. "vault:infocar".
+const VaultPrefix = "vault:"
+
+type secretRef struct {
+	name  string
+	value string
 }
 
 const (
-	UrlLogin     = "https://info-car.pl/oauth2/login"
-	UrlRefresh   = "https://info-car.pl/oauth2/authorize?response_type=id_token%20token&client_id=client&redirect_uri=https://info-car.pl/new/assets/refresh.html&scope=openid%20profile%20email%20resource.read&prompt=none"
-	UrlUserInfo  = "https://info-car.pl/oauth2/userinfo"
-	UrlScheadule = "https://info-car.pl/api/word/word-centers/exam-schedule"
-	UrlWords     = "https://info-car.pl/api/word/word-centers"
+	DefaultBaseUrl = "https://info-car.pl"
+
+	PathLogin       = "/oauth2/login"
+	PathAuthorize   = "/oauth2/authorize"
+	PathRefreshPage = "/new/assets/refresh.html"
+	PathUserInfo    = "/oauth2/userinfo"
+	PathScheadule   = "/api/word/word-centers/exam-schedule"
+	PathWords       = "/api/word/word-centers"
 )
 
+// Clone returns a copy that shares no slices or maps with c, so either can be
+// edited without affecting the other.
+func (c *Config) Clone() Config {
+	clone := *c
+	clone.Targets = append([]WORD(nil), c.Targets...)
+	clone.HTTP.UserAgents = append([]string(nil), c.HTTP.UserAgents...)
+	if c.refs != nil {
+		clone.refs = make(map[string]secretRef, len(c.refs))
+		for path, ref := range c.refs {
+			clone.refs[path] = ref
+		}
+	}
+	return clone
+}
+
+func (c *Config) InfocarBaseUrl() string {
+	if c.Monitor.BaseUrl == "" {
+		return DefaultBaseUrl
+	}
+	return strings.TrimSuffix(c.Monitor.BaseUrl, "/")
+}
+
+// ProxyURL returns the configured proxy, or "" when the proxy is disabled.
+// An address without a scheme is taken as an HTTP proxy.
+func (c *Config) ProxyURL() string {
+	if !c.Monitor.Proxy || c.Monitor.ProxyAddress == "" {
+		return ""
+	}
+	if strings.Contains(c.Monitor.ProxyAddress, "://") {
+		return c.Monitor.ProxyAddress
+	}
+	return "http://" + c.Monitor.ProxyAddress
+}
+
+// WatchTargets returns the configured targets, falling back to the single
+// word section used by older configs.
+func (c *Config) WatchTargets() []WORD {
+	if len(c.Targets) > 0 {
+		return c.Targets
+	}
+	if c.Word.WordId == "" {
+		return nil
+	}
+	return []WORD{c.Word}
+}
+
+// CatalogRefresh returns how often the word-centers catalog is refreshed,
+// once a day by default.
+func (c *Config) CatalogRefresh() time.Duration {
+	if c.Catalog.RefreshHours <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(c.Catalog.RefreshHours) * time.Hour
+}
+
+// DriftDir returns where responses that no longer match the expected schema
+// are saved.
+func (c *Config) DriftDir() string {
+	if c.Monitor.DriftDir == "" {
+		return "internal/state/drift"
+	}
+	return c.Monitor.DriftDir
+}
+
+// ParseClock returns the number of minutes since midnight for an "HH:MM" string.
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Validate reports values the monitor cannot work with. WORD IDs are checked
+// separately against the catalog.
+func (c *Config) Validate() []error {
+	var errs []error
+	if c.Credential.Username == "" || c.Credential.Password == "" {
+		errs = append(errs, errors.New("login i hasło info-car są wymagane"))
+	}
+	if len(c.WatchTargets()) == 0 {
+		errs = append(errs, errors.New("brak WORDów do monitorowania"))
+	}
+	for _, t := range c.WatchTargets() {
+		if t.WordId == "" || t.Category == "" {
+			errs = append(errs, fmt.Errorf("cel %q: WORD i kategoria są wymagane", t.WordId))
+		}
+		if t.MaxDays < 0 {
+			errs = append(errs, fmt.Errorf("cel %q: max dni nie może być ujemne", t.WordId))
+		}
+	}
+	for _, f := range []struct {
+		label string
+		value int
+	}{
+		{"interwał", c.Monitor.Interval},
+		{"okno tłumienia", c.Monitor.SuppressWindow},
+		{"próg alertów", c.Monitor.FailureThreshold},
+		{"odświeżanie katalogu", c.Catalog.RefreshHours},
+		{"minimalna liczba miejsc", c.Filter.MinPlaces},
+		{"maksymalna cena", c.Filter.MaxAmount},
+		{"limit czasu połączenia", c.HTTP.ConnectTimeout},
+		{"limit czasu odpowiedzi", c.HTTP.ReadTimeout},
+		{"limit czasu zapytania", c.HTTP.Timeout},
+		{"keep-alive", c.HTTP.KeepAlive},
+		{"czas bezczynnego połączenia", c.HTTP.IdleTimeout},
+		{"liczba bezczynnych połączeń", c.HTTP.MaxIdleConns},
+	} {
+		if f.value < 0 {
+			errs = append(errs, fmt.Errorf("%s nie może być ujemny", f.label))
+		}
+	}
+	for _, f := range []struct{ label, value string }{
+		{"godzina podsumowania", c.Monitor.DigestTime},
+		{"początek godzin ciszy", c.Webhook.QuietHours.Start},
+		{"koniec godzin ciszy", c.Webhook.QuietHours.End},
+		{"początek godzin ciszy (błędy)", c.Webhook.ErrorQuietHours.Start},
+		{"koniec godzin ciszy (błędy)", c.Webhook.ErrorQuietHours.End},
+	} {
+		if f.value == "" {
+			continue
+		}
+		if _, err := ParseClock(f.value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.label, err))
+		}
+	}
+	for _, f := range []struct{ label, value string }{
+		{"adres info-car", c.Monitor.BaseUrl},
+		{"Discord webhook", c.Webhook.DiscordURL},
+		{"Discord webhook błędów", c.Webhook.DiscordErrorURL},
+		{"heartbeat", c.Heartbeat.URL},
+		{"proxy", c.ProxyURL()},
+	} {
+		if f.value == "" {
+			continue
+		}
+		if u, err := url.Parse(f.value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s: nieprawidłowy adres URL %q", f.label, f.value))
+		}
+	}
+	if c.API.Address != "" {
+		if _, _, err := net.SplitHostPort(c.API.Address); err != nil {
+			errs = append(errs, fmt.Errorf("adres API %q: %w", c.API.Address, err))
+		} else if c.API.Public() && c.API.Password == "" {
+			errs = append(errs, fmt.Errorf("adres API %q jest dostępny spoza komputera, ustaw api.password", c.API.Address))
+		}
+	}
+	switch c.State.Backend {
+	case "", "file", "bolt":
+	case "redis":
+		if c.State.Redis.Addr == "" {
+			errs = append(errs, errors.New("backend redis wymaga adresu serwera"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("nieznany backend stanu %q", c.State.Backend))
+	}
+	switch c.Leader.Mode {
+	case "", "file":
+	case "redis":
+		if c.State.Redis.Addr == "" {
+			errs = append(errs, errors.New("wybór lidera przez redis wymaga adresu serwera w state.redis"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("nieznany tryb wyboru lidera %q", c.Leader.Mode))
+	}
+	switch strings.ToLower(c.Log.Format) {
+	case "", "text", "json":
+	default:
+		errs = append(errs, fmt.Errorf("nieznany format logów %q", c.Log.Format))
+	}
+	switch strings.ToLower(c.Log.Level) {
+	case "", "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("nieznany poziom logów %q", c.Log.Level))
+	}
+	switch c.Tracing.Exporter {
+	case "", "otlp", "stdout":
+	default:
+		errs = append(errs, fmt.Errorf("nieznany eksporter śladów %q", c.Tracing.Exporter))
+	}
+	if c.HTTP.CABundle != "" {
+		if _, err := os.Stat(c.HTTP.CABundle); err != nil {
+			errs = append(errs, fmt.Errorf("pakiet certyfikatów CA: %w", err))
+		}
+	}
+	if c.Leader.LeaseSeconds < 0 {
+		errs = append(errs, errors.New("czas ważności przywództwa nie może być ujemny"))
+	}
+	return errs
+}
+
+// secretFields lists the values that may be kept in the vault, by their yaml
+// path.
+func (c *Config) secretFields() map[string]*string {
+	return map[string]*string{
+		"credential.password":       &c.Credential.Password,
+		"credential.pesel":          &c.Credential.Pesel,
+		"credential.pkk":            &c.Credential.PKK,
+		"webhook.discord_url":       &c.Webhook.DiscordURL,
+		"webhook.discord_error_url": &c.Webhook.DiscordErrorURL,
+		"state.secret_key":          &c.State.SecretKey,
+		"state.redis.password":      &c.State.Redis.Password,
+		"api.password":              &c.API.Password,
+	}
+}
+
+// Secrets returns the configured secret values, including the webhook URLs
+// that embed their token, for masking in logs. Unresolved vault references
+// are left out.
+func (c *Config) Secrets() []string {
+	var values []string
+	for _, field := range c.secretFields() {
+		if *field != "" && !strings.HasPrefix(*field, VaultPrefix) {
+			values = append(values, *field)
+		}
+	}
+	return values
+}
+
+// localFields lists the settings naming files, directories or the info-car
+// server, by their yaml path.
+func (c *Config) localFields() map[string]*string {
+	return map[string]*string{
+		"monitor.base_url":   &c.Monitor.BaseUrl,
+		"monitor.record_dir": &c.Monitor.RecordDir,
+		"monitor.replay_dir": &c.Monitor.ReplayDir,
+		"monitor.drift_dir":  &c.Monitor.DriftDir,
+		"calendar.file":      &c.Calendar.File,
+		"leader.lock_file":   &c.Leader.LockFile,
+		"http.ca_bundle":     &c.HTTP.CABundle,
+	}
+}
+
+// LocalChanges returns the yaml paths of the file, directory and server
+// settings that differ from prev, sorted. Remote edits must not change them.
+func (c *Config) LocalChanges(prev *Config) []string {
+	old := prev.localFields()
+	var changed []string
+	for path, field := range c.localFields() {
+		if *field != *old[path] {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// ClearSecrets empties every secret, e.g. before the config leaves the
+// process.
+func (c *Config) ClearSecrets() {
+	for _, field := range c.secretFields() {
+		*field = ""
+	}
+}
+
+// KeepSecrets fills the secrets left empty with the ones from prev, so a
+// config edited without seeing its secrets does not erase them.
+func (c *Config) KeepSecrets(prev *Config) {
+	old := prev.secretFields()
+	for path, field := range c.secretFields() {
+		if *field == "" {
+			*field = *old[path]
+		}
+	}
+}
+
+// VaultRefs returns the names of the vault secrets the config refers to and
+// that are not resolved yet.
+func (c *Config) VaultRefs() []string {
+	var names []string
+	for _, field := range c.secretFields() {
+		if name, ok := strings.CutPrefix(*field, VaultPrefix); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ResolveSecrets replaces vault references with the secrets returned by
+// lookup. Save keeps writing the references.
+func (c *Config) ResolveSecrets(lookup func(name string) (string, bool)) error {
+	var missing []string
+	for path, field := range c.secretFields() {
+		name, ok := strings.CutPrefix(*field, VaultPrefix)
+		if !ok {
+			continue
+		}
+		value, ok := lookup(name)
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		if c.refs == nil {
+			c.refs = make(map[string]secretRef)
+		}
+		c.refs[path] = secretRef{name: name, value: value}
+		*field = value
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("brak sekretów w sejfie: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// MoveSecrets hands every secret kept in plain text to store, named by its
+// yaml path, and makes Save write a reference in its place.
+func (c *Config) MoveSecrets(store func(name, value string)) []string {
+	var moved []string
+	for path, field := range c.secretFields() {
+		if *field == "" || strings.HasPrefix(*field, VaultPrefix) {
+			continue
+		}
+		if ref, ok := c.refs[path]; ok && ref.value == *field {
+			continue
+		}
+		store(path, *field)
+		if c.refs == nil {
+			c.refs = make(map[string]secretRef)
+		}
+		c.refs[path] = secretRef{name: path, value: *field}
+		moved = append(moved, path)
+	}
+	return moved
+}
+
+// marshal encodes the config with vault references in place of the secrets
+// read from the vault. A secret changed since is written as is.
+func (c *Config) marshal() ([]byte, error) {
+	out := *c
+	fields := out.secretFields()
+	for path, ref := range c.refs {
+		if field := fields[path]; *field == ref.value {
+			*field = VaultPrefix + ref.name
+		}
+	}
+	return yaml.Marshal(&out)
+}
+
 func NewConfig() *Config {
 	config := &Config{}
 	config.Edit()
@@ -80,37 +564,85 @@ func Load(configPath string) (*Config, error) {
 }
 
 func (c *Config) Create(path string) error {
-	data, err := yaml.Marshal(c)
+	data, err := c.marshal()
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0644)
+	return os.WriteFile(path, data, 0600)
 }
 
 func (c *Config) Save(path string) error {
-	data, err := yaml.Marshal(c)
+	data, err := c.marshal()
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0644)
+	return os.WriteFile(path, data, 0600)
 }
 
 func (c *Config) Show() {
 	fmt.Println("\n--- KONFIGURACJA ---")
 	fmt.Printf("Login: %s\n", c.Credential.Username)
-	fmt.Printf("PKK: %s\n", c.Credential.PKK)
-	fmt.Printf("PESEL: %s\n", c.Credential.Pesel)
+	fmt.Printf("Hasło: %s\n", c.mask("credential.password", c.Credential.Password))
+	fmt.Printf("PKK: %s\n", c.mask("credential.pkk", c.Credential.PKK))
+	fmt.Printf("PESEL: %s\n", c.mask("credential.pesel", c.Credential.Pesel))
 	fmt.Printf("Phone: %s\n", c.Credential.Phone)
 	fmt.Printf("Email: %s\n", c.Credential.Email)
 
 	fmt.Printf("WORD ID: %s\n", c.Word.WordId)
 	fmt.Printf("Kategoria: %s\n", c.Word.Category)
 	fmt.Printf("Max dni do egzaminu: %d\n", c.Word.MaxDays)
+	for _, t := range c.Targets {
+		fmt.Printf("Cel: WORD %s, kategoria %s, max dni %d\n", t.WordId, t.Category, t.MaxDays)
+	}
 
+	fmt.Printf("Info-car: %s\n", c.InfocarBaseUrl())
+	if c.Monitor.RecordDir != "" {
+		fmt.Printf("Nagrywanie ruchu info-car do: %s\n", c.Monitor.RecordDir)
+	}
+	if c.Monitor.ReplayDir != "" {
+		fmt.Printf("Odtwarzanie ruchu info-car z: %s\n", c.Monitor.ReplayDir)
+	}
+	fmt.Printf("Filtr: min. miejsc %d, max. cena %d zł, info zawiera %q, info bez %q\n", c.Filter.MinPlaces, c.Filter.MaxAmount, c.Filter.InfoContains, c.Filter.InfoExcludes)
 	fmt.Printf("Interval (sekundy): %d\n", c.Monitor.Interval)
+	fmt.Printf("Odświeżanie katalogu WORD: %s\n", c.CatalogRefresh())
 	fmt.Printf("Proxy: %t (%s)\n", c.Monitor.Proxy, c.Monitor.ProxyAddress)
+	fmt.Printf("HTTP: połączenie %ds, odpowiedź %ds, zapytanie %ds, CA %q, User-Agent: %d\n", c.HTTP.ConnectTimeout, c.HTTP.ReadTimeout, c.HTTP.Timeout, c.HTTP.CABundle, len(c.HTTP.UserAgents))
+	fmt.Printf("Okno tłumienia powtórek (minuty, 0 = bez ponownych powiadomień): %d\n", c.Monitor.SuppressWindow)
+	fmt.Printf("Godzina dziennego podsumowania: %s\n", c.Monitor.DigestTime)
 
-	fmt.Printf("Discord: %s\n", c.Webhook.DiscordURL)
+	fmt.Printf("Discord: %s\n", c.mask("webhook.discord_url", c.Webhook.DiscordURL))
+	fmt.Printf("Godziny ciszy: %s-%s\n", c.Webhook.QuietHours.Start, c.Webhook.QuietHours.End)
+	fmt.Printf("Discord błędy: %s\n", c.mask("webhook.discord_error_url", c.Webhook.DiscordErrorURL))
+	fmt.Printf("Alert po kolejnych błędach: %d\n", c.Monitor.FailureThreshold)
+	fmt.Printf("Heartbeat: %s\n", c.Heartbeat.URL)
+	fmt.Printf("Backend stanu: %s\n", c.State.Backend)
+	fmt.Printf("Klucz zapisu sesji: %s\n", c.mask("state.secret_key", c.State.SecretKey))
+	if c.State.Backend == "redis" {
+		fmt.Printf("Redis: %s (db %d, prefiks %q)\n", c.State.Redis.Addr, c.State.Redis.DB, c.State.Redis.Prefix)
+	}
+	if c.Leader.Mode != "" {
+		fmt.Printf("Wybór lidera: %s (lease %ds)\n", c.Leader.Mode, c.Leader.LeaseSeconds)
+	}
+	fmt.Printf("API: %s\n", c.API.Address)
+	fmt.Printf("Plik kalendarza: %s\n", c.Calendar.File)
+	fmt.Printf("Logi: format %q, poziom %q\n", c.Log.Format, c.Log.Level)
+	if c.Tracing.Exporter != "" {
+		fmt.Printf("Śledzenie: %s %s\n", c.Tracing.Exporter, c.Tracing.Endpoint)
+	}
+}
+
+// mask hides a secret in Show, telling only whether it is set and whether it
+// comes from the vault.
+func (c *Config) mask(path, value string) string {
+	switch ref, ok := c.refs[path]; {
+	case value == "":
+		return "(brak)"
+	case ok && ref.value == value:
+		return "******** (sejf: " + ref.name + ")"
+	case strings.HasPrefix(value, VaultPrefix):
+		return value
+	}
+	return "********"
 }
 
 func (c *Config) Edit() {
@@ -128,6 +660,17 @@ func (c *Config) Edit() {
 		return t
 	}
 
+	// inputSecret never prints the current value, only whether it is set.
+	inputSecret := func(path, label, current string) string {
+		fmt.Printf("%s [%s]: ", label, c.mask(path, current))
+		scanner.Scan()
+		t := scanner.Text()
+		if t == "" {
+			return current
+		}
+		return t
+	}
+
 	inputBool := func(label string, current bool) bool {
 		fmt.Printf("%s [%t]: ", label, current)
 		scanner.Scan()
@@ -154,29 +697,81 @@ func (c *Config) Edit() {
 
 	// Dane logowania
 	c.Credential.Username = input("Login", c.Credential.Username)
-	c.Credential.Password = input("Hasło", c.Credential.Password)
-	c.Credential.Pesel = input("PESEL", c.Credential.Pesel)
-	c.Credential.PKK = input("PKK", c.Credential.PKK)
+	c.Credential.Password = inputSecret("credential.password", "Hasło", c.Credential.Password)
+	c.Credential.Pesel = inputSecret("credential.pesel", "PESEL", c.Credential.Pesel)
+	c.Credential.PKK = inputSecret("credential.pkk", "PKK", c.Credential.PKK)
 	c.Credential.Email = input("Email", c.Credential.Email)
 	c.Credential.Phone = input("Telefon", c.Credential.Phone)
 
 	// WORD
-	c.Word.WordId = input("WORD ID", c.Word.WordId)
+	c.Word.WordId = input("WORD ID lub nazwa", c.Word.WordId)
 	c.Word.Category = input("Kategoria", c.Word.Category)
 	c.Word.MaxDays = inputInt("Max dni do egzaminu", c.Word.MaxDays)
 
+	// Filtr
+	c.Filter.MinPlaces = inputInt("Minimalna liczba miejsc (0 = bez limitu)", c.Filter.MinPlaces)
+	c.Filter.MaxAmount = inputInt("Maksymalna cena w zł (0 = bez limitu)", c.Filter.MaxAmount)
+	c.Filter.InfoContains = input("Dodatkowe info musi zawierać (puste = dowolne)", c.Filter.InfoContains)
+	c.Filter.InfoExcludes = input("Dodatkowe info nie może zawierać (puste = brak)", c.Filter.InfoExcludes)
+
 	// Monitor
-	c.Monitor.UrlLogin = input("URL logowania", c.Monitor.UrlLogin)
-	c.Monitor.UrlCheck = input("URL sprawdzania", c.Monitor.UrlCheck)
+	c.Monitor.BaseUrl = input("Adres info-car (puste = "+DefaultBaseUrl+")", c.Monitor.BaseUrl)
 	c.Monitor.Interval = inputInt("Interwał (sekundy)", c.Monitor.Interval)
-	c.Monitor.HealthCheckInterval = inputInt("Interwał sprawdzania zdrowia ilosc interwalow", c.Monitor.HealthCheckInterval)
+	c.Catalog.RefreshHours = inputInt("Odświeżanie katalogu WORD (godziny, 0 = 24)", c.Catalog.RefreshHours)
 	c.Monitor.Proxy = inputBool("Używać proxy?", c.Monitor.Proxy)
 	c.Monitor.ProxyAddress = input("Adres proxy", c.Monitor.ProxyAddress)
+	c.HTTP.ConnectTimeout = inputInt("Limit czasu połączenia (sekundy, 0 = 10)", c.HTTP.ConnectTimeout)
+	c.HTTP.ReadTimeout = inputInt("Limit czasu odpowiedzi (sekundy, 0 = 30)", c.HTTP.ReadTimeout)
+	c.HTTP.Timeout = inputInt("Limit czasu całego zapytania (sekundy, 0 = 60)", c.HTTP.Timeout)
+	c.HTTP.CABundle = input("Plik z dodatkowymi certyfikatami CA (PEM, puste = systemowe)", c.HTTP.CABundle)
 	c.Monitor.Debug = inputBool("Debug", c.Monitor.Debug)
+	c.Log.Format = input("Format logów (text, json)", c.Log.Format)
+	c.Log.Level = input("Poziom logów (debug, info, warn, error, puste = wg Debug)", c.Log.Level)
 	c.Monitor.PracticeExams = inputBool("Sprawdzać praktyczne egzaminy? puste = false", c.Monitor.PracticeExams)
 	c.Monitor.TheoryExams = inputBool("Sprawdzać teoretyczne egzaminy? puste = false", c.Monitor.TheoryExams)
+	c.Monitor.SuppressWindow = inputInt("Powiadom ponownie o terminie, który zniknął i wrócił, najwcześniej po (minuty, 0 = nigdy)", c.Monitor.SuppressWindow)
+	c.Monitor.FailureThreshold = inputInt("Alert po ilu kolejnych błędach (0 = 3)", c.Monitor.FailureThreshold)
+	c.Monitor.DigestTime = input("Godzina dziennego podsumowania (HH:MM, puste = brak)", c.Monitor.DigestTime)
 
 	// Webhook
-	c.Webhook.DiscordURL = input("Discord webhook URL", c.Webhook.DiscordURL)
-	c.Webhook.DiscordHealthCheckUrl = input("Discord webhook URL health check", c.Webhook.DiscordHealthCheckUrl)
+	c.Webhook.DiscordURL = inputSecret("webhook.discord_url", "Discord webhook URL", c.Webhook.DiscordURL)
+	c.Webhook.QuietHours.Start = input("Początek godzin ciszy (HH:MM, puste = brak)", c.Webhook.QuietHours.Start)
+	c.Webhook.QuietHours.End = input("Koniec godzin ciszy (HH:MM)", c.Webhook.QuietHours.End)
+	c.Webhook.DiscordErrorURL = inputSecret("webhook.discord_error_url", "Discord webhook URL dla błędów (puste = główny)", c.Webhook.DiscordErrorURL)
+	c.Webhook.ErrorQuietHours.Start = input("Początek godzin ciszy dla błędów (HH:MM, puste = brak)", c.Webhook.ErrorQuietHours.Start)
+	c.Webhook.ErrorQuietHours.End = input("Koniec godzin ciszy dla błędów (HH:MM)", c.Webhook.ErrorQuietHours.End)
+
+	// Heartbeat
+	c.Heartbeat.URL = input("Heartbeat URL (np. https://hc-ping.com/<uuid>)", c.Heartbeat.URL)
+
+	// Stan
+	c.State.SecretKey = inputSecret("state.secret_key", "Klucz szyfrowania zapisanej sesji info-car (puste = logowanie przy każdym starcie)", c.State.SecretKey)
+	c.State.Backend = input("Backend stanu (file, bolt, redis)", c.State.Backend)
+	if c.State.Backend == "redis" {
+		c.State.Redis.Addr = input("Adres Redis (host:port)", c.State.Redis.Addr)
+		c.State.Redis.Password = inputSecret("state.redis.password", "Hasło Redis", c.State.Redis.Password)
+		c.State.Redis.DB = inputInt("Baza Redis", c.State.Redis.DB)
+		c.State.Redis.Prefix = input("Prefiks kluczy Redis (puste = word-monitor:)", c.State.Redis.Prefix)
+	}
+
+	// Redundancja
+	c.Leader.Mode = input("Wybór lidera między instancjami (file, redis, puste = wyłączony)", c.Leader.Mode)
+	if c.Leader.Mode == "file" {
+		c.Leader.LockFile = input("Plik blokady lidera", c.Leader.LockFile)
+	}
+	if c.Leader.Mode != "" {
+		c.Leader.LeaseSeconds = inputInt("Czas ważności przywództwa (sekundy, 0 = 30)", c.Leader.LeaseSeconds)
+	}
+
+	// API i kalendarz
+	c.API.Address = input("Adres API HTTP (np. :2115, puste = wyłączone)", c.API.Address)
+	c.API.Password = inputSecret("api.password", "Hasło do panelu WWW", c.API.Password)
+	c.Calendar.File = input("Plik kalendarza .ics (puste = brak)", c.Calendar.File)
+
+	// Śledzenie
+	c.Tracing.Exporter = input("Eksporter śladów OpenTelemetry (otlp, stdout, puste = wyłączone)", c.Tracing.Exporter)
+	if c.Tracing.Exporter == "otlp" {
+		c.Tracing.Endpoint = input("Adres kolektora OTLP/HTTP (np. localhost:4318)", c.Tracing.Endpoint)
+		c.Tracing.Insecure = inputBool("Połączenie bez TLS?", c.Tracing.Insecure)
+	}
 }
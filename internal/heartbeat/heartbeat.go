@@ -0,0 +1,67 @@
+package heartbeat
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Heartbeat pings a healthchecks.io-style endpoint: the base URL on success
+// and base URL + "/fail" on failure, with a plain-text report in the body.
+type Heartbeat struct {
+	url string
+}
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// SetClient replaces the HTTP client used for heartbeats.
+func SetClient(c *http.Client) {
+	client = c
+}
+
+func New(url string) *Heartbeat {
+	return &Heartbeat{url: strings.TrimSuffix(url, "/")}
+}
+
+func (h *Heartbeat) Enabled() bool {
+	return h.url != ""
+}
+
+func (h *Heartbeat) Success(lastSuccess time.Time, report string) error {
+	return h.ping(h.url, fmt.Sprintf("ok\nostatnie udane sprawdzenie: %s\n%s", formatTime(lastSuccess), report))
+}
+
+func (h *Heartbeat) Fail(lastSuccess time.Time, errs []error) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "błąd\nostatnie udane sprawdzenie: %s\n", formatTime(lastSuccess))
+	for _, err := range errs {
+		fmt.Fprintf(&b, "- %s\n", err)
+	}
+	return h.ping(h.url+"/fail", b.String())
+}
+
+func (h *Heartbeat) ping(url, body string) error {
+	if !h.Enabled() {
+		return nil
+	}
+	resp, err := client.Post(url, "text/plain; charset=utf-8", strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return errors.New("heartbeat failed: " + resp.Status)
+	}
+	slog.Debug("Wysłano heartbeat", "url", url)
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "brak"
+	}
+	return t.Format(time.RFC3339)
+}
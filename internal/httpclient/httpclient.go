@@ -0,0 +1,177 @@
+// Package httpclient builds the HTTP clients used for info-car and webhook
+// traffic from one configurable transport: timeouts, keep-alive, proxy,
+// extra CA certificates and browser-like headers for info-car.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/config"
+)
+
+const (
+	defaultConnectTimeout = 10 * time.Second
+	defaultReadTimeout    = 30 * time.Second
+	defaultTimeout        = 60 * time.Second
+	defaultKeepAlive      = 30 * time.Second
+	defaultIdleTimeout    = 90 * time.Second
+	defaultMaxIdleConns   = 10
+
+	defaultUserAgent      = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36"
+	defaultAcceptLanguage = "pl-PL,pl;q=0.9,en-US;q=0.8,en;q=0.7"
+)
+
+// Clients share one transport. Infocar sends browser-like headers, Plain is
+// for webhooks and other services.
+type Clients struct {
+	Infocar *http.Client
+	Plain   *http.Client
+	browser *BrowserTransport
+}
+
+// New builds the clients from the http and proxy settings of cfg.
+func New(cfg *config.Config) (*Clients, error) {
+	t, err := NewTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	timeout := seconds(cfg.HTTP.Timeout, defaultTimeout)
+	browser := NewBrowserTransport(t, cfg.HTTP)
+	return &Clients{
+		Infocar: &http.Client{Transport: browser, Timeout: timeout},
+		Plain:   &http.Client{Transport: t, Timeout: timeout},
+		browser: browser,
+	}, nil
+}
+
+// RotateUserAgent switches info-car requests to the next configured
+// User-Agent.
+func (c *Clients) RotateUserAgent() {
+	c.browser.Rotate()
+}
+
+// NewTransport returns a transport with the configured timeouts, keep-alive,
+// proxy and CA bundle. Without a configured proxy the HTTP(S)_PROXY
+// variables apply.
+func NewTransport(cfg *config.Config) (*http.Transport, error) {
+	h := cfg.HTTP
+	dialer := &net.Dialer{
+		Timeout:   seconds(h.ConnectTimeout, defaultConnectTimeout),
+		KeepAlive: seconds(h.KeepAlive, defaultKeepAlive),
+	}
+	t := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   seconds(h.ConnectTimeout, defaultConnectTimeout),
+		ResponseHeaderTimeout: seconds(h.ReadTimeout, defaultReadTimeout),
+		ExpectContinueTimeout: time.Second,
+		IdleConnTimeout:       seconds(h.IdleTimeout, defaultIdleTimeout),
+		MaxIdleConns:          positive(h.MaxIdleConns, defaultMaxIdleConns),
+		MaxIdleConnsPerHost:   positive(h.MaxIdleConns, defaultMaxIdleConns),
+	}
+	if proxy := cfg.ProxyURL(); proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("proxy %q: %w", proxy, err)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+	if h.CABundle != "" {
+		pool, err := certPool(h.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return t, nil
+}
+
+// certPool adds the PEM certificates in path to the system pool, so a
+// corporate or proxy CA is trusted next to the public ones.
+func certPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates found in " + path)
+	}
+	return pool, nil
+}
+
+// BrowserTransport sets the headers a browser would send when a request has
+// not set them itself.
+type BrowserTransport struct {
+	next           http.RoundTripper
+	acceptLanguage string
+
+	mu         sync.Mutex
+	userAgents []string
+	current    int
+}
+
+func NewBrowserTransport(next http.RoundTripper, h config.HTTP) *BrowserTransport {
+	agents := h.UserAgents
+	if len(agents) == 0 {
+		agents = []string{defaultUserAgent}
+	}
+	lang := h.AcceptLanguage
+	if lang == "" {
+		lang = defaultAcceptLanguage
+	}
+	return &BrowserTransport{next: next, acceptLanguage: lang, userAgents: agents}
+}
+
+// Rotate moves to the next User-Agent.
+func (t *BrowserTransport) Rotate() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.current = (t.current + 1) % len(t.userAgents)
+}
+
+func (t *BrowserTransport) userAgent() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.userAgents[t.current]
+}
+
+func (t *BrowserTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	set := func(key, value string) {
+		if req.Header.Get(key) == "" {
+			req.Header.Set(key, value)
+		}
+	}
+	set("User-Agent", t.userAgent())
+	set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	set("Accept-Language", t.acceptLanguage)
+	return t.next.RoundTrip(req)
+}
+
+func seconds(v int, fallback time.Duration) time.Duration {
+	if v <= 0 {
+		return fallback
+	}
+	return time.Duration(v) * time.Second
+}
+
+func positive(v, fallback int) int {
+	if v <= 0 {
+		return fallback
+	}
+	return v
+}
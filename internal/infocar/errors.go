@@ -0,0 +1,149 @@
+package infocar
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxBodySnippet = 512
+
+var ErrTokenExpired = errors.New("token is empty or expired")
+
+// RequestError is a non-2xx response from info-car that does not fall into
+// one of the more specific categories below.
+type RequestError struct {
+	Tag        string
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *RequestError) Error() string {
+	msg := "request failed"
+	if e.Status != "" {
+		msg += ": " + e.Status
+	}
+	if e.Tag != "" {
+		msg += " " + e.Tag
+	}
+	return msg
+}
+
+// Request returns the response details; it is promoted to every error type
+// embedding RequestError, so errors.As with RequestDetails matches all of them.
+func (e *RequestError) Request() *RequestError { return e }
+
+// RequestDetails is implemented by every info-car error that carries a status
+// code, tag and body snippet.
+type RequestDetails interface {
+	error
+	Request() *RequestError
+}
+
+// AuthError means the session is missing, expired or was rejected; logging in
+// again may fix it.
+type AuthError struct {
+	RequestError
+	Err error
+}
+
+func (e *AuthError) Error() string {
+	if e.Err != nil {
+		return e.Tag + ": " + e.Err.Error()
+	}
+	return "auth failed: " + e.RequestError.Error()
+}
+
+func (e *AuthError) Unwrap() error { return e.Err }
+
+// RateLimitedError is a 429 response. RetryAfter is zero when info-car did
+// not say how long to wait.
+type RateLimitedError struct {
+	RequestError
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return "rate limited: " + e.RequestError.Error()
+}
+
+// MaintenanceError means info-car is down or behind a maintenance page.
+type MaintenanceError struct {
+	RequestError
+}
+
+func (e *MaintenanceError) Error() string {
+	return "maintenance: " + e.RequestError.Error()
+}
+
+// SchemaError means the response could not be decoded into the expected type.
+type SchemaError struct {
+	RequestError
+	Err error
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("unexpected response schema %s: %v", e.Tag, e.Err)
+}
+
+func (e *SchemaError) Unwrap() error { return e.Err }
+
+// NetworkError wraps a transport failure: DNS, connection, TLS or timeout.
+type NetworkError struct {
+	Tag string
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return "network error " + e.Tag + ": " + e.Err.Error()
+}
+
+func (e *NetworkError) Unwrap() error { return e.Err }
+
+// responseError classifies a non-2xx response and consumes up to
+// maxBodySnippet bytes of its body.
+func responseError(resp *http.Response, tag string) error {
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodySnippet))
+	base := RequestError{
+		Tag:        tag,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       strings.TrimSpace(string(snippet)),
+	}
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return &AuthError{RequestError: base}
+	case http.StatusTooManyRequests:
+		return &RateLimitedError{RequestError: base, RetryAfter: retryAfter(resp.Header.Get("Retry-After"))}
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return &MaintenanceError{RequestError: base}
+	default:
+		return &base
+	}
+}
+
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+func networkError(err error, tag string) error {
+	return &NetworkError{Tag: tag, Err: err}
+}
+
+func schemaError(err error, tag string) error {
+	return &SchemaError{RequestError: RequestError{Tag: tag}, Err: err}
+}
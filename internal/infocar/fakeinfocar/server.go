@@ -0,0 +1,281 @@
+// Package fakeinfocar is an in-process stand-in for info-car.pl. It serves the
+// login page with a _csrf token, the OAuth refresh redirect with the token in
+// the URL fragment, the word-centers list and a scriptable exam schedule, so
+// the infocar client and the monitor can run without network access.
+package fakeinfocar
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kapi1023/word-monitor/internal/config"
+	"github.com/kapi1023/word-monitor/internal/infocar"
+)
+
+const (
+	sessionCookie = "SESSION"
+	authCookie    = "AUTH"
+)
+
+// Response is a scripted exam-schedule answer. Schedule is served as JSON
+// unless Body is set; Status defaults to 200. Header is added to the reply,
+// e.g. Retry-After with a 429 status.
+type Response struct {
+	Status   int
+	Header   http.Header
+	Body     string
+	Schedule *infocar.ExamScheduleResponse
+}
+
+// ScheduleFunc answers an exam-schedule request.
+type ScheduleFunc func(req infocar.ExamScheduleRequest) Response
+
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	username  string
+	password  string
+	expiresIn int
+	words     infocar.AvailableWords
+	schedule  ScheduleFunc
+	sessions  map[string]string
+	authed    map[string]bool
+	tokens    map[string]bool
+	logins    int
+	requests  []infocar.ExamScheduleRequest
+}
+
+// New starts a server on a random local port accepting the given
+// credentials. Close must be called when done.
+func New(username, password string) *Server {
+	s := NewUnstarted(username, password)
+	s.Start()
+	return s
+}
+
+// NewUnstarted returns a server that is not listening yet, so the caller can
+// replace its Listener before calling Start.
+func NewUnstarted(username, password string) *Server {
+	s := &Server{
+		username:  username,
+		password:  password,
+		expiresIn: 3600,
+		sessions:  make(map[string]string),
+		authed:    make(map[string]bool),
+		tokens:    make(map[string]bool),
+		schedule: func(req infocar.ExamScheduleRequest) Response {
+			return Response{Schedule: &infocar.ExamScheduleResponse{Category: req.Category, OrganizationId: req.WordID}}
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+config.PathLogin, s.loginPage)
+	mux.HandleFunc("POST "+config.PathLogin, s.login)
+	mux.HandleFunc("GET "+config.PathAuthorize, s.authorize)
+	mux.HandleFunc("GET "+config.PathRefreshPage, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html></html>")
+	})
+	mux.HandleFunc("GET "+config.PathUserInfo, s.userInfo)
+	mux.HandleFunc("GET "+config.PathWords, s.wordCenters)
+	mux.HandleFunc("PUT "+config.PathScheadule, s.examSchedule)
+	s.Server = httptest.NewUnstartedServer(mux)
+	return s
+}
+
+func (s *Server) SetWords(words infocar.AvailableWords) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.words = words
+}
+
+// SetSchedule makes every exam-schedule request return resp.
+func (s *Server) SetSchedule(resp infocar.ExamScheduleResponse) {
+	s.SetScheduleFunc(func(infocar.ExamScheduleRequest) Response {
+		return Response{Schedule: &resp}
+	})
+}
+
+func (s *Server) SetScheduleFunc(fn ScheduleFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schedule = fn
+}
+
+// SetTokenLifetime sets expires_in, in seconds, for tokens issued from now on.
+func (s *Server) SetTokenLifetime(seconds int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expiresIn = seconds
+}
+
+// RevokeTokens invalidates every issued bearer token and login session.
+func (s *Server) RevokeTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = make(map[string]bool)
+	s.authed = make(map[string]bool)
+}
+
+// Logins returns the number of successful logins.
+func (s *Server) Logins() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logins
+}
+
+// ScheduleRequests returns every exam-schedule request received so far.
+func (s *Server) ScheduleRequests() []infocar.ExamScheduleRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]infocar.ExamScheduleRequest(nil), s.requests...)
+}
+
+func (s *Server) loginPage(w http.ResponseWriter, r *http.Request) {
+	session, csrf := randomToken(), randomToken()
+	s.mu.Lock()
+	s.sessions[session] = csrf
+	s.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: session, Path: "/"})
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, `<html><body><form method="post" action="%s">
+<input type="text" name="username"/>
+<input type="password" name="password"/>
+<input type="hidden" name="_csrf" value="%s"/>
+</form></body></html>`, config.PathLogin, csrf)
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		http.Error(w, "no session", http.StatusForbidden)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if csrf, ok := s.sessions[cookie.Value]; !ok || csrf != r.PostForm.Get("_csrf") {
+		http.Error(w, "invalid csrf token", http.StatusForbidden)
+		return
+	}
+	if r.PostForm.Get("username") != s.username || r.PostForm.Get("password") != s.password {
+		http.Error(w, "bad credentials", http.StatusUnauthorized)
+		return
+	}
+	s.authed[cookie.Value] = true
+	s.logins++
+	http.SetCookie(w, &http.Cookie{Name: authCookie, Value: cookie.Value, Path: "/"})
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	redirect := r.URL.Query().Get("redirect_uri")
+	cookie, err := r.Cookie(authCookie)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil || !s.authed[cookie.Value] {
+		http.Redirect(w, r, redirect+"#error=login_required", http.StatusFound)
+		return
+	}
+	token := randomToken()
+	s.tokens[token] = true
+	http.Redirect(w, r, redirect+"#access_token="+token+"&token_type=Bearer&expires_in="+strconv.Itoa(s.expiresIn), http.StatusFound)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return ok && s.tokens[token]
+}
+
+func (s *Server) userInfo(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, infocar.UserInfo{
+		Sub:               s.username,
+		PreferredUsername: s.username,
+		Email:             s.username,
+	})
+}
+
+func (s *Server) wordCenters(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	words := s.words
+	s.mu.Unlock()
+
+	data, err := json.Marshal(words)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func (s *Server) examSchedule(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req infocar.ExamScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	schedule := s.schedule
+	s.mu.Unlock()
+
+	resp := schedule(req)
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if resp.Body != "" || resp.Schedule == nil {
+		w.WriteHeader(status)
+		fmt.Fprint(w, resp.Body)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp.Schedule)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func randomToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
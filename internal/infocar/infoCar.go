@@ -1,11 +1,12 @@
 package infocar
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"log/slog"
 	"net/http"
-	"net/http/cookiejar"
 	"net/url"
 	"strings"
 	"time"
@@ -15,9 +16,18 @@ import (
 )
 
 type InfocarClient struct {
+	baseURL      string
 	client       *http.Client
+	jar          *sessionJar
 	token        string
 	tokenExpires time.Time
+	onDrift      DriftHandler
+	onLogin      func()
+
+	// Set by PersistSession.
+	sessionPath string
+	sessionSalt []byte
+	sessionKey  []byte
 }
 
 type UserInfo struct {
@@ -73,157 +83,213 @@ type TheoryExams struct {
 	AdditionalInfo interface{} `json:"additionalInfo"`
 }
 
-func NewCLient() *InfocarClient {
-	jar, _ := cookiejar.New(nil)
+// NewCLient returns a client for baseURL sending requests with hc, which
+// provides the transport and timeout; the client keeps its own cookie jar.
+func NewCLient(baseURL string, hc *http.Client) *InfocarClient {
+	jar := newSessionJar()
+	client := *hc
+	client.Jar = jar
 	return &InfocarClient{
-		client: &http.Client{
-			Jar: jar,
-		},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &client,
+		jar:     jar,
 	}
 }
 
+// OnLogin registers a function called before every full login, e.g. to
+// rotate the User-Agent.
+func (i *InfocarClient) OnLogin(f func()) {
+	i.onLogin = f
+}
+
+func (i *InfocarClient) url(path string) string {
+	return i.baseURL + path
+}
+
 func (i *InfocarClient) DoRequest(req *http.Request, tag string) (*http.Response, error) {
 	if err := i.BearerAuth(req); err != nil {
 		return nil, err
 	}
-	resp, err := i.client.Do(req)
+	resp, err := i.send(req, tag)
 	if err != nil {
-		return nil, err
+		return nil, networkError(err, tag)
 	}
-	slog.Debug(tag, slog.String("status", resp.Status))
+	slog.DebugContext(req.Context(), tag, slog.String("status", resp.Status))
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("request failed: " + resp.Status + " " + tag)
+		defer resp.Body.Close()
+		err := responseError(resp, tag)
+		var authErr *AuthError
+		if errors.As(err, &authErr) {
+			i.dropSession(req.Context())
+		}
+		return nil, err
 	}
 	return resp, nil
 }
 
-func (i *InfocarClient) GetCSRFToken(targetURL string) (string, error) {
-	resp, err := i.client.Get(targetURL)
+func (i *InfocarClient) GetCSRFToken(ctx context.Context, targetURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
 	if err != nil {
 		return "", err
 	}
+	resp, err := i.send(req, "GetCSRFToken")
+	if err != nil {
+		return "", networkError(err, "GetCSRFToken")
+	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", responseError(resp, "GetCSRFToken")
+	}
 
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
 	if err != nil {
-		return "", err
+		return "", schemaError(err, "GetCSRFToken")
 	}
 
 	csrf, exists := doc.Find("input[name='_csrf']").Attr("value")
 	if !exists {
-		return "", errors.New("_csrf token not found")
+		return "", schemaError(errors.New("_csrf token not found"), "GetCSRFToken")
 	}
 
 	return csrf, nil
 }
 
-func (i *InfocarClient) Login(username, password string) error {
-	csrfToken, err := i.GetCSRFToken(config.UrlLogin)
+// Login signs in with the credentials and obtains a token. The new session is
+// saved when PersistSession was set up, so a restart does not log in again.
+func (i *InfocarClient) Login(ctx context.Context, username, password string) error {
+	if i.onLogin != nil {
+		i.onLogin()
+	}
+	csrfToken, err := i.GetCSRFToken(ctx, i.url(config.PathLogin))
 	if err != nil {
 		return err
 	}
 
-	slog.Debug("CSRF token", slog.String("token", csrfToken))
+	slog.DebugContext(ctx, "CSRF token", slog.Int("length", len(csrfToken)))
 	form := url.Values{}
 	form.Add("username", username)
 	form.Add("password", password)
 	form.Add("_csrf", csrfToken)
 
-	req, err := http.NewRequest("POST", config.UrlLogin, strings.NewReader(form.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", i.url(config.PathLogin), strings.NewReader(form.Encode()))
 	if err != nil {
 		return err
 	}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/58.0.3029.110 Safari/537.3")
 	req.Header.Set("Accept", "application/json, text/javascript, */*; q=0.01")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
 	req.Header.Set("X-Requested-With", "XMLHttpRequest")
 	req.Header.Set("X-CSRF-Token", csrfToken)
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	resp, err := i.client.Do(req)
+	resp, err := i.send(req, "Login")
 	if err != nil {
-		return err
+		return networkError(err, "Login")
 	}
 	defer resp.Body.Close()
 
-	slog.Debug("Login response", slog.String("status", resp.Status))
+	slog.DebugContext(ctx, "Login response", slog.String("status", resp.Status))
 	if resp.StatusCode != http.StatusOK {
-		return errors.New("login failed: " + resp.Status)
+		return responseError(resp, "Login")
 	}
 
-	return i.RefreshToken()
+	if err := i.refreshToken(ctx); err != nil {
+		return err
+	}
+	i.saveSession(ctx)
+	return nil
 }
 
-func (i *InfocarClient) RefreshToken() error {
-	resp, err := i.client.Get(config.UrlRefresh)
+// RefreshToken obtains a new token with the session cookies and saves the
+// session.
+func (i *InfocarClient) RefreshToken(ctx context.Context) error {
+	if err := i.refreshToken(ctx); err != nil {
+		return err
+	}
+	i.saveSession(ctx)
+	return nil
+}
+
+func (i *InfocarClient) refreshToken(ctx context.Context) error {
+	refreshURL := i.url(config.PathAuthorize) +
+		"?response_type=id_token%20token&client_id=client&redirect_uri=" + i.url(config.PathRefreshPage) +
+		"&scope=openid%20profile%20email%20resource.read&prompt=none"
+	req, err := http.NewRequestWithContext(ctx, "GET", refreshURL, nil)
 	if err != nil {
 		return err
 	}
+	resp, err := i.send(req, "RefreshToken")
+	if err != nil {
+		return networkError(err, "RefreshToken")
+	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp, "RefreshToken")
+	}
 
 	fragment := resp.Request.URL.Fragment
 	if fragment == "" {
-		return errors.New("no token found in URL fragment")
+		return &AuthError{RequestError: RequestError{Tag: "RefreshToken"}, Err: errors.New("no token found in URL fragment")}
 	}
 
 	values, err := url.ParseQuery(fragment)
 	if err != nil {
-		return err
+		return schemaError(err, "RefreshToken")
 	}
 
 	token := values.Get("access_token")
 	expiresIn := values.Get("expires_in")
 	if token == "" || expiresIn == "" {
-		return errors.New("access token or expires_in not found in URL fragment")
+		return &AuthError{RequestError: RequestError{Tag: "RefreshToken"}, Err: errors.New("access token or expires_in not found in URL fragment")}
 	}
 
 	duration, err := time.ParseDuration(expiresIn + "s")
 	if err != nil {
-		return err
+		return schemaError(err, "RefreshToken")
 	}
 	i.token = token
 	i.tokenExpires = time.Now().Add(duration)
 
-	slog.Debug("Token", slog.String("bearer", i.token), slog.Time("expires", i.tokenExpires))
+	slog.DebugContext(ctx, "Token", slog.Time("expires", i.tokenExpires))
 	return nil
 }
 
 func (i *InfocarClient) BearerAuth(req *http.Request) error {
 	if i.token == "" || time.Now().After(i.tokenExpires) {
-		return errors.New("token is empty or expired")
+		return &AuthError{RequestError: RequestError{Tag: "BearerAuth"}, Err: ErrTokenExpired}
 	}
 	req.Header.Set("Authorization", "Bearer "+i.token)
 	return nil
 }
 
 func (i *InfocarClient) GetUserInfo() (*UserInfo, error) {
-	req, err := http.NewRequest("GET", config.UrlUserInfo, nil)
+	req, err := http.NewRequest("GET", i.url(config.PathUserInfo), nil)
 	if err != nil {
 		return nil, err
 	}
-	if err := i.BearerAuth(req); err != nil {
-		return nil, err
-	}
-	resp, err := i.client.Do(req)
+	resp, err := i.DoRequest(req, "GetUserInfo")
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("request failed: " + resp.Status)
-	}
-
 	var userInfo UserInfo
 	if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
-		return nil, err
+		return nil, schemaError(err, "GetUserInfo")
 	}
 	return &userInfo, nil
 }
 
 var format string = "2025-04-21T19:23:03.483Z"
 
-func (i *InfocarClient) GetExamSchedule(category, wordID string, start, end time.Time) (*ExamScheduleResponse, error) {
+// Without these fields every slot would silently disappear from the decoded
+// schedule.
+var scheduleRequired = []string{
+	"schedule",
+	"schedule.scheduledDays",
+	"schedule.scheduledDays[].day",
+	"schedule.scheduledDays[].scheduledHours[].time",
+}
+
+func (i *InfocarClient) GetExamSchedule(ctx context.Context, category, wordID string, start, end time.Time) (*ExamScheduleResponse, error) {
 	reqBody := ExamScheduleRequest{
 		Category: category,
 		WordID:   wordID,
@@ -235,8 +301,8 @@ func (i *InfocarClient) GetExamSchedule(category, wordID string, start, end time
 		return nil, err
 	}
 
-	slog.Debug("ExamScheduleRequest", slog.String("body", string(body)))
-	req, err := http.NewRequest("PUT", config.UrlScheadule, strings.NewReader(string(body)))
+	slog.DebugContext(ctx, "ExamScheduleRequest", slog.String("body", string(body)))
+	req, err := http.NewRequestWithContext(ctx, "PUT", i.url(config.PathScheadule), strings.NewReader(string(body)))
 	if err != nil {
 		return nil, err
 	}
@@ -249,8 +315,12 @@ func (i *InfocarClient) GetExamSchedule(category, wordID string, start, end time
 	}
 	defer resp.Body.Close()
 
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, networkError(err, "GetExamSchedule")
+	}
 	var scheduleResponse ExamScheduleResponse
-	if err := json.NewDecoder(resp.Body).Decode(&scheduleResponse); err != nil {
+	if err := i.decode(ctx, data, &scheduleResponse, "GetExamSchedule", scheduleRequired...); err != nil {
 		return nil, err
 	}
 	return &scheduleResponse, nil
@@ -0,0 +1,242 @@
+package infocar_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/infocar"
+	"github.com/kapi1023/word-monitor/internal/infocar/fakeinfocar"
+)
+
+func newClient(t *testing.T) (*fakeinfocar.Server, *infocar.InfocarClient) {
+	t.Helper()
+	s := fakeinfocar.New("user", "secret")
+	t.Cleanup(s.Close)
+	return s, infocar.NewCLient(s.URL, &http.Client{Timeout: 5 * time.Second})
+}
+
+func TestLoginSendsCSRFToken(t *testing.T) {
+	s, client := newClient(t)
+	ctx := context.Background()
+
+	csrf, err := client.GetCSRFToken(ctx, s.URL+"/oauth2/login")
+	if err != nil {
+		t.Fatalf("GetCSRFToken: %v", err)
+	}
+	if csrf == "" {
+		t.Fatal("GetCSRFToken returned an empty token")
+	}
+
+	// The fake rejects a login whose _csrf does not match the session cookie
+	// set by the login page.
+	if err := client.Login(ctx, "user", "secret"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if got := s.Logins(); got != 1 {
+		t.Errorf("Logins() = %d, want 1", got)
+	}
+	info, err := client.GetUserInfo()
+	if err != nil {
+		t.Fatalf("GetUserInfo after login: %v", err)
+	}
+	if info.PreferredUsername != "user" {
+		t.Errorf("PreferredUsername = %q, want %q", info.PreferredUsername, "user")
+	}
+}
+
+func TestLoginWrongPassword(t *testing.T) {
+	s, client := newClient(t)
+
+	err := client.Login(context.Background(), "user", "wrong")
+	var authErr *infocar.AuthError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("Login error = %v, want *AuthError", err)
+	}
+	if authErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("StatusCode = %d, want %d", authErr.StatusCode, http.StatusUnauthorized)
+	}
+	if got := s.Logins(); got != 0 {
+		t.Errorf("Logins() = %d, want 0", got)
+	}
+}
+
+func TestRevokedSessionNeedsLogin(t *testing.T) {
+	s, client := newClient(t)
+	ctx := context.Background()
+	if err := client.Login(ctx, "user", "secret"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	// Once the login session is revoked neither the token nor a refresh works.
+	s.RevokeTokens()
+	if _, err := client.GetUserInfo(); err == nil {
+		t.Fatal("GetUserInfo with a revoked token succeeded")
+	}
+	if err := client.RefreshToken(ctx); err == nil {
+		t.Fatal("RefreshToken after the login session was revoked succeeded")
+	}
+}
+
+func TestRefreshTokenWithoutSession(t *testing.T) {
+	_, client := newClient(t)
+
+	err := client.RefreshToken(context.Background())
+	var authErr *infocar.AuthError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("RefreshToken error = %v, want *AuthError", err)
+	}
+	if !strings.Contains(err.Error(), "fragment") {
+		t.Errorf("RefreshToken error = %q, want it to mention the URL fragment", err)
+	}
+}
+
+func TestRefreshTokenExpiry(t *testing.T) {
+	s, client := newClient(t)
+	ctx := context.Background()
+	s.SetTokenLifetime(0)
+	if err := client.Login(ctx, "user", "secret"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	// expires_in=0 leaves the client without a usable token.
+	var authErr *infocar.AuthError
+	if _, err := client.GetUserInfo(); !errors.As(err, &authErr) || !errors.Is(err, infocar.ErrTokenExpired) {
+		t.Fatalf("GetUserInfo error = %v, want ErrTokenExpired", err)
+	}
+
+	s.SetTokenLifetime(3600)
+	if err := client.RefreshToken(ctx); err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	if _, err := client.GetUserInfo(); err != nil {
+		t.Fatalf("GetUserInfo after refresh: %v", err)
+	}
+	if got := s.Logins(); got != 1 {
+		t.Errorf("Logins() = %d, want 1", got)
+	}
+}
+
+func TestGetExamScheduleDecodes(t *testing.T) {
+	s, client := newClient(t)
+	ctx := context.Background()
+	s.SetSchedule(infocar.ExamScheduleResponse{
+		Category:       "B",
+		OrganizationId: "7",
+		Schedule: infocar.Schedule{ScheduledDays: []infocar.ScheduleDays{{
+			Day: "2030-01-02",
+			ScheduledHours: []infocar.ScheduledHours{{
+				Time: "08:30:00",
+				PracticeExams: []infocar.PracticeExams{
+					{ID: "p1", Places: 2, Date: "2030-01-02T08:30:00", Amount: 200, AdditionalInfo: "plac manewrowy"},
+				},
+				TheoryExams: []infocar.TheoryExams{
+					{ID: "t1", Places: 9, Date: "2030-01-02T08:30:00", Amount: 50},
+				},
+			}},
+		}}},
+	})
+	if err := client.Login(ctx, "user", "secret"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	resp, err := client.GetExamSchedule(ctx, "B", "7", start, start.AddDate(0, 0, 30))
+	if err != nil {
+		t.Fatalf("GetExamSchedule: %v", err)
+	}
+	days := resp.Schedule.ScheduledDays
+	if len(days) != 1 || len(days[0].ScheduledHours) != 1 {
+		t.Fatalf("ScheduledDays = %+v, want one day with one hour", days)
+	}
+	hour := days[0].ScheduledHours[0]
+	if hour.Time != "08:30:00" {
+		t.Errorf("Time = %q, want %q", hour.Time, "08:30:00")
+	}
+	if len(hour.PracticeExams) != 1 || hour.PracticeExams[0].ID != "p1" || hour.PracticeExams[0].Places != 2 || hour.PracticeExams[0].Amount != 200 {
+		t.Errorf("PracticeExams = %+v", hour.PracticeExams)
+	}
+	if got := infocar.InfoText(hour.PracticeExams[0].AdditionalInfo); got != "plac manewrowy" {
+		t.Errorf("InfoText = %q, want %q", got, "plac manewrowy")
+	}
+	if len(hour.TheoryExams) != 1 || hour.TheoryExams[0].ID != "t1" {
+		t.Errorf("TheoryExams = %+v", hour.TheoryExams)
+	}
+
+	reqs := s.ScheduleRequests()
+	if len(reqs) != 1 || reqs[0].WordID != "7" || reqs[0].Category != "B" {
+		t.Errorf("ScheduleRequests() = %+v, want one request for WORD 7 category B", reqs)
+	}
+}
+
+func TestGetExamScheduleErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		resp  fakeinfocar.Response
+		check func(t *testing.T, err error)
+	}{
+		{
+			name: "rate limited",
+			resp: fakeinfocar.Response{Status: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"120"}}},
+			check: func(t *testing.T, err error) {
+				var e *infocar.RateLimitedError
+				if !errors.As(err, &e) {
+					t.Fatalf("error = %v, want *RateLimitedError", err)
+				}
+				if e.RetryAfter != 2*time.Minute {
+					t.Errorf("RetryAfter = %s, want 2m", e.RetryAfter)
+				}
+			},
+		},
+		{
+			name: "maintenance page",
+			resp: fakeinfocar.Response{Status: http.StatusServiceUnavailable, Body: "<html>Przerwa techniczna</html>"},
+			check: func(t *testing.T, err error) {
+				var e *infocar.MaintenanceError
+				if !errors.As(err, &e) {
+					t.Fatalf("error = %v, want *MaintenanceError", err)
+				}
+				if !strings.Contains(e.Body, "Przerwa techniczna") {
+					t.Errorf("Body = %q, want the maintenance page", e.Body)
+				}
+			},
+		},
+		{
+			name: "session expired",
+			resp: fakeinfocar.Response{Status: http.StatusUnauthorized},
+			check: func(t *testing.T, err error) {
+				var e *infocar.AuthError
+				if !errors.As(err, &e) {
+					t.Fatalf("error = %v, want *AuthError", err)
+				}
+			},
+		},
+		{
+			name: "not json",
+			resp: fakeinfocar.Response{Body: "<html>logowanie</html>"},
+			check: func(t *testing.T, err error) {
+				var e *infocar.SchemaError
+				if !errors.As(err, &e) {
+					t.Fatalf("error = %v, want *SchemaError", err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, client := newClient(t)
+			ctx := context.Background()
+			if err := client.Login(ctx, "user", "secret"); err != nil {
+				t.Fatalf("Login: %v", err)
+			}
+			s.SetScheduleFunc(func(infocar.ExamScheduleRequest) fakeinfocar.Response { return tt.resp })
+
+			start := time.Now()
+			_, err := client.GetExamSchedule(ctx, "B", "1", start, start.AddDate(0, 0, 7))
+			tt.check(t, err)
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package infocar
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/config"
+)
+
+const redacted = "REDACTED"
+
+// Exchange is a single recorded request/response pair with credentials,
+// tokens and personal data replaced by REDACTED.
+type Exchange struct {
+	Recorded time.Time        `json:"recorded"`
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Record saves every exchange made by the client to dir.
+func (i *InfocarClient) Record(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	i.client.Transport = &recordTransport{next: i.transport(), dir: dir}
+	return nil
+}
+
+// Replay serves responses from exchanges previously recorded to dir instead
+// of contacting info-car.
+func (i *InfocarClient) Replay(dir string) error {
+	t, err := newReplayTransport(dir)
+	if err != nil {
+		return err
+	}
+	i.client.Transport = t
+	return nil
+}
+
+func (i *InfocarClient) transport() http.RoundTripper {
+	if i.client.Transport != nil {
+		return i.client.Transport
+	}
+	return http.DefaultTransport
+}
+
+type recordTransport struct {
+	next http.RoundTripper
+	dir  string
+	mu   sync.Mutex
+	seq  int
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	ex := Exchange{
+		Recorded: time.Now(),
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: redactHeader(req.Header),
+			Body:   redactBody(req.URL.Path, req.Header.Get("Content-Type"), reqBody),
+		},
+		Response: RecordedResponse{
+			Status: resp.StatusCode,
+			Header: redactHeader(resp.Header),
+			Body:   redactBody(req.URL.Path, resp.Header.Get("Content-Type"), respBody),
+		},
+	}
+	if err := t.save(ex); err != nil {
+		return nil, fmt.Errorf("record exchange: %w", err)
+	}
+	return resp, nil
+}
+
+func (t *recordTransport) save(ex Exchange) error {
+	t.mu.Lock()
+	t.seq++
+	seq := t.seq
+	t.mu.Unlock()
+
+	data, err := json.MarshalIndent(ex, "", "  ")
+	if err != nil {
+		return err
+	}
+	u, _ := url.Parse(ex.Request.URL)
+	name := fmt.Sprintf("%s-%04d-%s-%s.json",
+		ex.Recorded.Format("20060102T150405.000"),
+		seq,
+		ex.Request.Method,
+		strings.ReplaceAll(strings.Trim(u.Path, "/"), "/", "_"),
+	)
+	return os.WriteFile(filepath.Join(t.dir, name), data, 0644)
+}
+
+// replayTransport answers requests with recorded responses matched by method
+// and path, in recording order. The last response for a path is repeated once
+// the recording is exhausted, so a replayed monitor can keep polling.
+type replayTransport struct {
+	mu        sync.Mutex
+	exchanges map[string][]Exchange
+	next      map[string]int
+}
+
+func newReplayTransport(dir string) (*replayTransport, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no recorded exchanges in " + dir)
+	}
+	sort.Strings(files)
+
+	t := &replayTransport{
+		exchanges: make(map[string][]Exchange),
+		next:      make(map[string]int),
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var ex Exchange
+		if err := json.Unmarshal(data, &ex); err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		u, err := url.Parse(ex.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		key := ex.Request.Method + " " + u.Path
+		t.exchanges[key] = append(t.exchanges[key], ex)
+	}
+	return t, nil
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	key := req.Method + " " + req.URL.Path
+
+	t.mu.Lock()
+	recorded := t.exchanges[key]
+	n := t.next[key]
+	if n < len(recorded)-1 {
+		t.next[key]++
+	}
+	t.mu.Unlock()
+	if len(recorded) == 0 {
+		return nil, errors.New("no recorded response for " + key)
+	}
+
+	ex := recorded[n]
+	header := ex.Response.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	// Redaction changes body sizes.
+	header.Del("Content-Length")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", ex.Response.Status, http.StatusText(ex.Response.Status)),
+		StatusCode:    ex.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(ex.Response.Body)),
+		ContentLength: int64(len(ex.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+var (
+	secretHeaders = []string{"Authorization", "Cookie", "X-Csrf-Token"}
+	secretParams  = []string{"username", "password", "_csrf", "access_token", "id_token", "refresh_token"}
+	secretFields  = map[string]bool{
+		"username": true, "password": true, "pesel": true, "pkk": true, "email": true, "phone": true,
+		"access_token": true, "id_token": true, "refresh_token": true,
+		"sub": true, "preferred_username": true, "given_name": true, "family_name": true,
+	}
+	csrfInput  = regexp.MustCompile(`(name=['"]_csrf['"][^>]*value=['"])[^'"]*`)
+	tokenParam = regexp.MustCompile(`((?:access_token|id_token|refresh_token)=)[^&"'\s]+`)
+)
+
+func redactHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range secretHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, redacted)
+		}
+	}
+	for i, c := range out.Values("Set-Cookie") {
+		name, rest, _ := strings.Cut(c, "=")
+		_, attrs, found := strings.Cut(rest, ";")
+		out["Set-Cookie"][i] = name + "=" + redacted
+		if found {
+			out["Set-Cookie"][i] += ";" + attrs
+		}
+	}
+	if loc := out.Get("Location"); loc != "" {
+		if u, err := url.Parse(loc); err == nil && u.Fragment != "" {
+			u.Fragment = redactParams(u.Fragment)
+			out.Set("Location", u.String())
+		}
+	}
+	return out
+}
+
+func redactParams(encoded string) string {
+	values, err := url.ParseQuery(encoded)
+	if err != nil {
+		return redacted
+	}
+	for _, p := range secretParams {
+		if values.Has(p) {
+			values.Set(p, redacted)
+		}
+	}
+	return values.Encode()
+}
+
+func redactBody(path, contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	switch {
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		return redactParams(string(body))
+	case strings.Contains(contentType, "json"):
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return redacted
+		}
+		// userinfo carries the account holder's full name under "name",
+		// which elsewhere is just a WORD center or province name.
+		redactJSON(v, path == config.PathUserInfo)
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		body := csrfInput.ReplaceAllString(string(body), "${1}"+redacted)
+		return tokenParam.ReplaceAllString(body, "${1}"+redacted)
+	}
+}
+
+func redactJSON(v any, withName bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if secretFields[strings.ToLower(k)] || (withName && k == "name") {
+				v[k] = redacted
+				continue
+			}
+			redactJSON(child, withName)
+		}
+	case []any:
+		for _, child := range v {
+			redactJSON(child, withName)
+		}
+	}
+}
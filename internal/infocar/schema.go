@@ -0,0 +1,168 @@
+package infocar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Drift lists the JSON fields that differ from the Go type a payload was
+// decoded into, as dotted paths with "[]" for array elements. Only required
+// fields are reported as missing, info-car leaves out optional ones freely.
+type Drift struct {
+	Unknown []string
+	Missing []string
+}
+
+func (d Drift) Empty() bool {
+	return len(d.Unknown) == 0 && len(d.Missing) == 0
+}
+
+func (d Drift) String() string {
+	var parts []string
+	if len(d.Unknown) > 0 {
+		parts = append(parts, "nieznane pola: "+strings.Join(d.Unknown, ", "))
+	}
+	if len(d.Missing) > 0 {
+		parts = append(parts, "brakujące pola: "+strings.Join(d.Missing, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// DriftHandler is called with the raw payload whenever a response does not
+// match the expected schema exactly.
+type DriftHandler func(ctx context.Context, tag string, drift Drift, raw []byte)
+
+// OnDrift registers the handler for schema drift in decoded responses.
+func (i *InfocarClient) OnDrift(h DriftHandler) {
+	i.onDrift = h
+}
+
+// decode unmarshals data into v and reports fields that were added to the
+// payload or required paths that are gone compared to v's type. Drift is only
+// an error when a required path is missing, otherwise the decoded value is
+// usable.
+func (i *InfocarClient) decode(ctx context.Context, data []byte, v any, tag string, required ...string) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return schemaError(err, tag)
+	}
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return schemaError(err, tag)
+	}
+	drift := CheckSchema(raw, reflect.TypeOf(v), required...)
+	if drift.Empty() {
+		return nil
+	}
+	if i.onDrift != nil {
+		i.onDrift(ctx, tag, drift, data)
+	}
+	if len(drift.Missing) > 0 {
+		return schemaError(fmt.Errorf("required field %s is missing", drift.Missing[0]), tag)
+	}
+	return nil
+}
+
+// CheckSchema compares a generically decoded JSON value with the type it is
+// meant to be decoded into. Field names match case-insensitively, like in
+// json.Unmarshal, and only the required paths are reported when missing.
+func CheckSchema(raw any, t reflect.Type, required ...string) Drift {
+	want := make(map[string]bool, len(required))
+	for _, path := range required {
+		want[path] = true
+	}
+	unknown := make(map[string]bool)
+	missing := make(map[string]bool)
+	walk(raw, t, "", want, unknown, missing)
+	return Drift{Unknown: sortedKeys(unknown), Missing: sortedKeys(missing)}
+}
+
+func walk(raw any, t reflect.Type, path string, required, unknown, missing map[string]bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := raw.(map[string]any)
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		for name, f := range fields {
+			value, ok := lookup(obj, name)
+			if !ok {
+				if required[join(path, name)] {
+					missing[join(path, name)] = true
+				}
+				continue
+			}
+			walk(value, f.Type, join(path, name), required, unknown, missing)
+		}
+		for name := range obj {
+			if _, ok := lookup(fields, name); !ok {
+				unknown[join(path, name)] = true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		arr, ok := raw.([]any)
+		if !ok {
+			return
+		}
+		for _, elem := range arr {
+			walk(elem, t.Elem(), path+"[]", required, unknown, missing)
+		}
+	}
+}
+
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+// lookup finds name in m the way json.Unmarshal matches object keys to
+// fields: an exact match first, otherwise any key equal under case folding.
+func lookup[V any](m map[string]V, name string) (V, bool) {
+	if v, ok := m[name]; ok {
+		return v, true
+	}
+	for key, v := range m {
+		if strings.EqualFold(key, name) {
+			return v, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return fmt.Sprintf("%s.%s", path, name)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,67 @@
+package infocar_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/kapi1023/word-monitor/internal/infocar"
+)
+
+func TestCheckSchema(t *testing.T) {
+	required := []string{"schedule", "schedule.scheduledDays", "schedule.scheduledDays[].day"}
+	tests := []struct {
+		name    string
+		payload string
+		unknown []string
+		missing []string
+	}{
+		{
+			name:    "exact",
+			payload: `{"category":"B","organizationId":"1","schedule":{"scheduledDays":[{"day":"2030-01-02","scheduledHours":[]}]}}`,
+		},
+		{
+			name:    "optional fields absent",
+			payload: `{"schedule":{"scheduledDays":[{"day":"2030-01-02","scheduledHours":[{"time":"08:00:00","practiceExams":[{"id":"p1"}]}]}]}}`,
+		},
+		{
+			name:    "case differs",
+			payload: `{"Category":"B","OrganizationID":"1","Schedule":{"ScheduledDays":[{"DAY":"2030-01-02"}]}}`,
+		},
+		{
+			name:    "unknown fields",
+			payload: `{"schedule":{"scheduledDays":[{"day":"2030-01-02","holiday":true}]},"version":2}`,
+			unknown: []string{"schedule.scheduledDays[].holiday", "version"},
+		},
+		{
+			name:    "required field missing",
+			payload: `{"schedule":{"scheduledDays":[{"date":"2030-01-02"}]}}`,
+			unknown: []string{"schedule.scheduledDays[].date"},
+			missing: []string{"schedule.scheduledDays[].day"},
+		},
+		{
+			name:    "required object missing",
+			payload: `{"category":"B"}`,
+			missing: []string{"schedule"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw any
+			if err := json.Unmarshal([]byte(tt.payload), &raw); err != nil {
+				t.Fatal(err)
+			}
+			drift := infocar.CheckSchema(raw, reflect.TypeOf(infocar.ExamScheduleResponse{}), required...)
+			if !slices.Equal(drift.Unknown, tt.unknown) {
+				t.Errorf("Unknown = %v, want %v", drift.Unknown, tt.unknown)
+			}
+			if !slices.Equal(drift.Missing, tt.missing) {
+				t.Errorf("Missing = %v, want %v", drift.Missing, tt.missing)
+			}
+			if drift.Empty() != (len(tt.unknown) == 0 && len(tt.missing) == 0) {
+				t.Errorf("Empty() = %t for %+v", drift.Empty(), drift)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package infocar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/atomicfile"
+	"github.com/kapi1023/word-monitor/internal/secretbox"
+)
+
+// sessionMaxAge bounds how long a saved session is reused, also when its
+// cookies do not expire.
+const sessionMaxAge = 24 * time.Hour
+
+const sessionVersion = 1
+
+var sessionAD = []byte("word-monitor info-car session v1")
+
+type savedCookie struct {
+	URL    string       `json:"url"`
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+type savedSession struct {
+	BaseURL string        `json:"base_url"`
+	Token   string        `json:"token"`
+	Expires time.Time     `json:"expires"`
+	Cookies []savedCookie `json:"cookies"`
+	Saved   time.Time     `json:"saved"`
+}
+
+type sessionFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Data    []byte `json:"data"`
+}
+
+// sessionJar is a cookie jar that remembers the cookies it was given, since
+// cookiejar.Jar cannot list them for saving.
+type sessionJar struct {
+	mu      sync.Mutex
+	jar     *cookiejar.Jar
+	cookies map[string]savedCookie
+}
+
+func newSessionJar() *sessionJar {
+	j := &sessionJar{}
+	j.reset()
+	return j
+}
+
+func (j *sessionJar) reset() {
+	jar, _ := cookiejar.New(nil)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jar = jar
+	j.cookies = make(map[string]savedCookie)
+}
+
+func (j *sessionJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jar.SetCookies(u, cookies)
+	for _, c := range cookies {
+		key := u.Host + "|" + c.Domain + "|" + c.Path + "|" + c.Name
+		if c.MaxAge < 0 || (!c.Expires.IsZero() && c.Expires.Before(time.Now())) {
+			delete(j.cookies, key)
+			continue
+		}
+		saved := *c
+		if saved.MaxAge > 0 {
+			saved.Expires = time.Now().Add(time.Duration(saved.MaxAge) * time.Second)
+			saved.MaxAge = 0
+		}
+		j.cookies[key] = savedCookie{URL: u.String(), Cookie: &saved}
+	}
+}
+
+func (j *sessionJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.jar.Cookies(u)
+}
+
+func (j *sessionJar) saved() []savedCookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var cookies []savedCookie
+	for _, c := range j.cookies {
+		if c.Cookie.Expires.IsZero() || c.Cookie.Expires.After(time.Now()) {
+			cookies = append(cookies, c)
+		}
+	}
+	return cookies
+}
+
+func (j *sessionJar) restore(cookies []savedCookie) {
+	for _, c := range cookies {
+		u, err := url.Parse(c.URL)
+		if err != nil || c.Cookie == nil {
+			continue
+		}
+		j.SetCookies(u, []*http.Cookie{c.Cookie})
+	}
+}
+
+func (j *sessionJar) empty() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.cookies) == 0
+}
+
+// PersistSession saves the cookies and bearer token to path, encrypted with
+// a key derived from secret, after every login or token refresh, and
+// restores a session saved there earlier. It reports whether one was
+// restored.
+func (i *InfocarClient) PersistSession(path, secret string) (bool, error) {
+	var f sessionFile
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return false, err
+	default:
+		if err := json.Unmarshal(data, &f); err != nil || f.Version != sessionVersion {
+			slog.Warn("Nieprawidłowy plik sesji info-car, pomijam", "path", path)
+			f = sessionFile{}
+		}
+	}
+	if f.Salt == nil {
+		if f.Salt, err = secretbox.NewSalt(); err != nil {
+			return false, err
+		}
+	}
+	key, err := secretbox.Key(secret, f.Salt)
+	if err != nil {
+		return false, err
+	}
+	i.sessionPath, i.sessionSalt, i.sessionKey = path, f.Salt, key
+	if f.Data == nil {
+		return false, nil
+	}
+
+	plain, err := secretbox.Open(key, f.Data, sessionAD)
+	if err != nil {
+		return false, fmt.Errorf("session %s: %w", path, err)
+	}
+	var s savedSession
+	if err := json.Unmarshal(plain, &s); err != nil {
+		return false, fmt.Errorf("session %s: %w", path, err)
+	}
+	if s.BaseURL != i.baseURL || time.Since(s.Saved) > sessionMaxAge {
+		return false, nil
+	}
+	i.jar.restore(s.Cookies)
+	if time.Now().Before(s.Expires) {
+		i.token, i.tokenExpires = s.Token, s.Expires
+	}
+	return true, nil
+}
+
+func (i *InfocarClient) saveSession(ctx context.Context) {
+	if i.sessionKey == nil {
+		return
+	}
+	if err := i.writeSession(); err != nil {
+		slog.WarnContext(ctx, "Nie udało się zapisać sesji info-car", "path", i.sessionPath, "err", err)
+	}
+}
+
+func (i *InfocarClient) writeSession() error {
+	plain, err := json.Marshal(savedSession{
+		BaseURL: i.baseURL,
+		Token:   i.token,
+		Expires: i.tokenExpires,
+		Cookies: i.jar.saved(),
+		Saved:   time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	sealed, err := secretbox.Seal(i.sessionKey, plain, sessionAD)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(sessionFile{Version: sessionVersion, Salt: i.sessionSalt, Data: sealed})
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(i.sessionPath, data, 0600, 0)
+}
+
+// Resume reuses the current session instead of a full login: a valid token
+// is kept and an expired one is refreshed with the session cookies. It
+// reports whether that worked.
+func (i *InfocarClient) Resume(ctx context.Context) bool {
+	if i.token != "" && time.Now().Before(i.tokenExpires) {
+		return true
+	}
+	if i.jar.empty() {
+		return false
+	}
+	if err := i.RefreshToken(ctx); err != nil {
+		slog.DebugContext(ctx, "Sesja info-car wygasła", "err", err)
+		return false
+	}
+	return true
+}
+
+// dropSession forgets a session info-car rejected, so the next Resume fails
+// and a full login follows.
+func (i *InfocarClient) dropSession(ctx context.Context) {
+	i.token, i.tokenExpires = "", time.Time{}
+	i.jar.reset()
+	if i.sessionKey != nil {
+		if err := os.Remove(i.sessionPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.WarnContext(ctx, "Nie udało się usunąć sesji info-car", "path", i.sessionPath, "err", err)
+		}
+	}
+}
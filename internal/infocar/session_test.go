@@ -0,0 +1,81 @@
+package infocar_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/infocar"
+)
+
+func TestPersistSession(t *testing.T) {
+	s, client := newClient(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "session.enc")
+
+	if restored, err := client.PersistSession(path, "klucz"); err != nil || restored {
+		t.Fatalf("PersistSession without a file = %t, %v", restored, err)
+	}
+	if err := client.Login(ctx, "user", "secret"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("session not saved after Login: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// A restarted client resumes the saved session without logging in.
+	restarted := infocar.NewCLient(s.URL, &http.Client{Timeout: 5 * time.Second})
+	if restored, err := restarted.PersistSession(path, "klucz"); err != nil || !restored {
+		t.Fatalf("PersistSession = %t, %v; want the session restored", restored, err)
+	}
+	if !restarted.Resume(ctx) {
+		t.Fatal("Resume of the restored session failed")
+	}
+	if _, err := restarted.GetUserInfo(); err != nil {
+		t.Fatalf("GetUserInfo with the restored session: %v", err)
+	}
+	if got := s.Logins(); got != 1 {
+		t.Errorf("Logins() = %d, want 1", got)
+	}
+
+	// Once info-car rejects the session it is dropped.
+	s.RevokeTokens()
+	var authErr *infocar.AuthError
+	if _, err := restarted.GetUserInfo(); !errors.As(err, &authErr) {
+		t.Fatalf("GetUserInfo with a revoked session = %v, want *AuthError", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Stat after the session was rejected = %v, want the file removed", err)
+	}
+	if restarted.Resume(ctx) {
+		t.Error("Resume after the session was rejected succeeded")
+	}
+}
+
+func TestPersistSessionWrongKey(t *testing.T) {
+	s, client := newClient(t)
+	path := filepath.Join(t.TempDir(), "session.enc")
+	if _, err := client.PersistSession(path, "klucz"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Login(context.Background(), "user", "secret"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	other := infocar.NewCLient(s.URL, &http.Client{Timeout: 5 * time.Second})
+	restored, err := other.PersistSession(path, "inny klucz")
+	if err == nil || restored {
+		t.Fatalf("PersistSession with a wrong key = %t, %v; want an error", restored, err)
+	}
+	if other.Resume(context.Background()) {
+		t.Error("Resume without a restored session succeeded")
+	}
+}
@@ -0,0 +1,36 @@
+package infocar
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kapi1023/word-monitor/internal/tracing"
+)
+
+var tracer = otel.Tracer("github.com/kapi1023/word-monitor/internal/infocar")
+
+// send performs req in an "infocar.<tag>" span. Only the path is recorded,
+// query strings may carry OAuth parameters.
+func (i *InfocarClient) send(req *http.Request, tag string) (*http.Response, error) {
+	ctx, span := tracer.Start(req.Context(), "infocar."+tag,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("url.path", req.URL.Path),
+		))
+	resp, err := i.client.Do(req.WithContext(ctx))
+	if err == nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		// The body is left for the caller, which turns it into an error.
+		if resp.StatusCode >= http.StatusBadRequest {
+			span.SetStatus(codes.Error, resp.Status)
+		}
+	}
+	tracing.End(span, err)
+	return resp, err
+}
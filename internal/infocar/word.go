@@ -1,14 +1,11 @@
 package infocar
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
+	"io"
 	"net/http"
-	"strconv"
-	"strings"
 	"time"
 
-	"github.com/kapi1023/word-monitor/internal/cache"
 	"github.com/kapi1023/word-monitor/internal/config"
 )
 
@@ -35,138 +32,39 @@ type Word struct {
 	Time       time.Time `json:"-"`
 }
 
-func GetWords() ([]Word, error) {
-	req, err := http.NewRequest("GET", config.UrlWords, nil)
+// FetchWordCenters downloads the word-centers list. When etag matches the
+// current version info-car answers 304 and words is nil.
+func (i *InfocarClient) FetchWordCenters(ctx context.Context, etag string) (words *AvailableWords, newEtag string, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", i.url(config.PathWords), nil)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("request failed: " + resp.Status)
-	}
-
-	var availableWords AvailableWords
-	if err := json.NewDecoder(resp.Body).Decode(&availableWords); err != nil {
-		return nil, err
-	}
-
-	return availableWords.Words, nil
-}
-
-func GetProvinces() ([]string, error) {
-	req, err := http.NewRequest("GET", config.UrlWords, nil)
-	if err != nil {
-		return nil, err
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := i.send(req, "FetchWordCenters")
 	if err != nil {
-		return nil, err
+		return nil, "", networkError(err, "FetchWordCenters")
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("request failed: " + resp.Status)
-	}
-
-	var availableWords AvailableWords
-	if err := json.NewDecoder(resp.Body).Decode(&availableWords); err != nil {
-		return nil, err
-	}
-
-	var provinces []string
-	for _, province := range availableWords.Provinces {
-		provinces = append(provinces, province.Name)
-	}
-	return provinces, nil
-}
-
-func GetWordsByProvince(provinceName string) ([]Word, error) {
-	req, err := http.NewRequest("GET", config.UrlWords, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, etag, nil
 	}
-	defer resp.Body.Close()
-
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("request failed: " + resp.Status)
-	}
-
-	var availableWords AvailableWords
-	if err := json.NewDecoder(resp.Body).Decode(&availableWords); err != nil {
-		return nil, err
-	}
-	var searchedProvinceId int
-	for _, province := range availableWords.Provinces {
-		if strings.Contains(strings.ToLower(province.Name), strings.ToLower(provinceName)) {
-			searchedProvinceId = province.ID
-			break
-		}
-	}
-	if searchedProvinceId == 0 {
-		return nil, errors.New("province not found")
-	}
-
-	var searchedWords []Word
-	for _, word := range availableWords.Words {
-		if word.ProvinceID == searchedProvinceId {
-			searchedWords = append(searchedWords, word)
-		}
-	}
-
-	return searchedWords, nil
-}
-func (i *InfocarClient) GetWordById(cache *cache.Cache[Word], wordId int) (Word, error) {
-	idStr := strconv.Itoa(wordId)
-
-	if cachedWord, err := cache.Get(idStr); err == nil {
-		return *cachedWord, nil
+		return nil, "", responseError(resp, "FetchWordCenters")
 	}
 
-	req, err := http.NewRequest("GET", config.UrlWords, nil)
-	if err != nil {
-		return Word{}, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return Word{}, err
+		return nil, "", networkError(err, "FetchWordCenters")
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return Word{}, errors.New("request failed: " + resp.Status)
-	}
-
 	var availableWords AvailableWords
-	if err := json.NewDecoder(resp.Body).Decode(&availableWords); err != nil {
-		return Word{}, err
-	}
-
-	for _, word := range availableWords.Words {
-		_ = cache.Set(strconv.Itoa(word.ID), word)
-		if word.ID == wordId {
-			return word, nil
-		}
+	if err := i.decode(ctx, data, &availableWords, "FetchWordCenters", "provinces", "words", "words[].id", "words[].name"); err != nil {
+		return nil, "", err
 	}
-	return Word{}, errors.New("word not found")
+	return &availableWords, resp.Header.Get("ETag"), nil
 }
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package leader
+
+import "errors"
+
+func newFileLease(path, id string) (lease, error) {
+	return nil, errors.New("blokada pliku lidera jest dostępna tylko na systemach uniksowych")
+}
@@ -0,0 +1,57 @@
+//go:build unix
+
+package leader
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// fileLease holds an exclusive flock on a file. The kernel drops the lock
+// when the process exits, so a standby instance on the same host takes over
+// on its next attempt.
+type fileLease struct {
+	path string
+	id   string
+	file *os.File
+}
+
+func newFileLease(path, id string) (*fileLease, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return &fileLease{path: path, id: id}, nil
+}
+
+func (l *fileLease) hold() (bool, error) {
+	if l.file != nil {
+		return true, nil
+	}
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return false, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return false, nil
+		}
+		return false, err
+	}
+	// The owner is informational, e.g. for "cat leader.lock".
+	_ = f.Truncate(0)
+	_, _ = f.WriteAt([]byte(l.id+"\n"), 0)
+	l.file = f
+	return true, nil
+}
+
+func (l *fileLease) release() error {
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
@@ -0,0 +1,163 @@
+// Package leader elects one active monitor among redundant instances, so only
+// that one polls info-car and sends notifications.
+package leader
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/config"
+)
+
+const (
+	ModeFile  = "file"
+	ModeRedis = "redis"
+
+	defaultLease    = 30 * time.Second
+	defaultLockFile = "internal/state/leader.lock"
+)
+
+// lease is the shared resource instances compete for. hold acquires or
+// renews it and reports whether this instance owns it.
+type lease interface {
+	hold() (bool, error)
+	release() error
+}
+
+type Elector struct {
+	id       string
+	lease    lease
+	interval time.Duration
+
+	mu       sync.Mutex
+	leading  bool
+	onChange func(leading bool)
+	// changed wakes the goroutine calling onChange, so a slow callback never
+	// delays renewing the lease.
+	changed chan struct{}
+}
+
+// New returns nil when leader election is disabled.
+func New(cfg config.Leader, redisCfg config.RedisState) (*Elector, error) {
+	ttl := defaultLease
+	if cfg.LeaseSeconds > 0 {
+		ttl = time.Duration(cfg.LeaseSeconds) * time.Second
+	}
+	id := cfg.ID
+	if id == "" {
+		host, _ := os.Hostname()
+		id = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+
+	var l lease
+	var err error
+	switch cfg.Mode {
+	case "":
+		return nil, nil
+	case ModeFile:
+		path := cfg.LockFile
+		if path == "" {
+			path = defaultLockFile
+		}
+		l, err = newFileLease(path, id)
+	case ModeRedis:
+		l, err = newRedisLease(redisCfg, id, ttl)
+	default:
+		return nil, fmt.Errorf("nieznany tryb wyboru lidera %q", cfg.Mode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Elector{id: id, lease: l, interval: ttl / 3, changed: make(chan struct{}, 1)}, nil
+}
+
+// ID identifies this instance in the lease and in notifications.
+func (e *Elector) ID() string {
+	return e.id
+}
+
+// OnChange registers f to be called whenever this instance gains or loses
+// leadership. f runs on its own goroutine; changes made while it is busy are
+// coalesced into a call with the latest state.
+func (e *Elector) OnChange(f func(leading bool)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onChange = f
+}
+
+func (e *Elector) Leading() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leading
+}
+
+// Start makes the first attempt to take the lease and keeps renewing it in
+// the background until stop is closed. The lease is then released, letting a
+// standby instance take over right away.
+func (e *Elector) Start(stop <-chan struct{}) {
+	go e.dispatch(stop)
+	e.renew()
+	go func() {
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				e.set(false)
+				if err := e.lease.release(); err != nil {
+					slog.Warn("Błąd zwalniania przywództwa", "err", err)
+				}
+				return
+			case <-ticker.C:
+				e.renew()
+			}
+		}
+	}()
+}
+
+// renew steps down when the lease cannot be renewed, since another instance
+// may already have taken it.
+func (e *Elector) renew() {
+	leading, err := e.lease.hold()
+	if err != nil {
+		slog.Warn("Błąd odnawiania przywództwa", "err", err)
+		leading = false
+	}
+	e.set(leading)
+}
+
+func (e *Elector) set(leading bool) {
+	e.mu.Lock()
+	changed := e.leading != leading
+	e.leading = leading
+	e.mu.Unlock()
+	if changed {
+		select {
+		case e.changed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// dispatch calls onChange with every leadership state that differs from the
+// one last reported.
+func (e *Elector) dispatch(stop <-chan struct{}) {
+	var reported bool
+	for {
+		select {
+		case <-stop:
+			return
+		case <-e.changed:
+		}
+		e.mu.Lock()
+		leading, f := e.leading, e.onChange
+		e.mu.Unlock()
+		if leading != reported && f != nil {
+			reported = leading
+			f(leading)
+		}
+	}
+}
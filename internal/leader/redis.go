@@ -0,0 +1,73 @@
+package leader
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/kapi1023/word-monitor/internal/config"
+)
+
+const redisTimeout = 5 * time.Second
+
+// Only the owner may extend or drop the lease.
+var (
+	renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// redisLease is a key holding the leader's ID that expires unless renewed,
+// so a standby takes over once the leader stops renewing it.
+type redisLease struct {
+	client *redis.Client
+	key    string
+	id     string
+	ttl    time.Duration
+}
+
+func newRedisLease(cfg config.RedisState, id string, ttl time.Duration) (*redisLease, error) {
+	prefix := cfg.Prefix
+	if prefix == "" {
+		prefix = "word-monitor:"
+	}
+	client := redis.NewClient(&redis.Options{Addr: cfg.Addr, Password: cfg.Password, DB: cfg.DB})
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &redisLease{client: client, key: prefix + "leader", id: id, ttl: ttl}, nil
+}
+
+func (l *redisLease) hold() (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	renewed, err := renewScript.Run(ctx, l.client, []string{l.key}, l.id, l.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	if renewed == 1 {
+		return true, nil
+	}
+	return l.client.SetNX(ctx, l.key, l.id, l.ttl).Result()
+}
+
+func (l *redisLease) release() error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	err := releaseScript.Run(ctx, l.client, []string{l.key}, l.id).Err()
+	if cerr := l.client.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+// Package logging configures the application's slog output: text or JSON,
+// a level from the config or flags, secrets redacted from every record and
+// the poll cycle's request ID attached to its lines.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+var level slog.LevelVar
+
+// ParseLevel accepts debug, info, warn and error. Empty means info.
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", s)
+	}
+	return l, nil
+}
+
+// Setup replaces the default logger with a redacting handler writing format
+// to w.
+func Setup(w io.Writer, format string, l slog.Level) error {
+	level.Set(l)
+	opts := &slog.HandlerOptions{Level: &level}
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		h = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+	slog.SetDefault(slog.New(Redact(h)))
+	return nil
+}
+
+// Level returns the level set by the last Setup.
+func Level() slog.Level {
+	return level.Level()
+}
@@ -0,0 +1,130 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const mask = "***"
+
+// sensitiveKeys are attribute key fragments whose values are never logged.
+var sensitiveKeys = []string{"token", "bearer", "csrf", "password", "passwd", "secret", "authorization", "cookie", "pesel", "pkk"}
+
+var (
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[a-z0-9._~+/=-]+`)
+	// peselPattern matches 11 digit numbers, the format of a PESEL.
+	peselPattern = regexp.MustCompile(`\b\d{11}\b`)
+)
+
+var (
+	mu      sync.RWMutex
+	secrets []string
+)
+
+// AddSecrets registers values, e.g. the configured password and PKK, that are
+// masked wherever they appear in log messages and attributes. Values shorter
+// than 4 characters are ignored, masking them would garble the logs.
+func AddSecrets(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range values {
+		if len(v) >= 4 && !slices.Contains(secrets, v) {
+			secrets = append(secrets, v)
+		}
+	}
+	// Longer secrets first, so one containing another is masked whole.
+	slices.SortFunc(secrets, func(a, b string) int { return len(b) - len(a) })
+}
+
+// Redact wraps h so secrets never reach it and records logged with a context
+// from WithRequestID carry the request ID.
+func Redact(h slog.Handler) slog.Handler {
+	return &redactHandler{next: h}
+}
+
+type redactHandler struct {
+	next slog.Handler
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.next.Enabled(ctx, l)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, redactString(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(a))
+		return true
+	})
+	if id := RequestID(ctx); id != "" {
+		out.AddAttrs(slog.String(requestIDKey, id))
+	}
+	return h.next.Handle(ctx, out)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return &redactHandler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if sensitiveKey(a.Key) && !a.Value.Equal(slog.StringValue("")) {
+		return slog.String(a.Key, mask)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, redactString(a.Value.String()))
+	case slog.KindGroup:
+		group := a.Value.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, g := range group {
+			redacted[i] = redactAttr(g)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case error:
+			return slog.String(a.Key, redactString(v.Error()))
+		case []byte:
+			return slog.String(a.Key, redactString(string(v)))
+		default:
+			// Structs and Stringers are logged as text, so they cannot carry
+			// a secret past the masking.
+			return slog.String(a.Key, redactString(fmt.Sprintf("%+v", v)))
+		}
+	}
+	return a
+}
+
+func sensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range sensitiveKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactString(s string) string {
+	mu.RLock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, mask)
+	}
+	mu.RUnlock()
+	s = bearerPattern.ReplaceAllString(s, "${1}"+mask)
+	return peselPattern.ReplaceAllString(s, mask)
+}
@@ -0,0 +1,130 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+type request struct {
+	URL  string
+	Body string
+}
+
+type webhook string
+
+func (w webhook) String() string { return "webhook " + string(w) }
+
+func TestRedact(t *testing.T) {
+	AddSecrets("hunter22", "https://discord.com/api/webhooks/1/abcdef", "abc")
+
+	tests := []struct {
+		name   string
+		msg    string
+		attrs  []any
+		hidden []string
+		kept   []string
+	}{
+		{
+			name:   "sensitive key",
+			msg:    "login",
+			attrs:  []any{"password", "plain", "csrf_token", "xyz", "Cookie", "SESSION=1"},
+			hidden: []string{"plain", "xyz", "SESSION=1"},
+			kept:   []string{"password=***", "csrf_token=***", "Cookie=***"},
+		},
+		{
+			name:  "empty sensitive value",
+			msg:   "login",
+			attrs: []any{"password", ""},
+			kept:  []string{`password=""`},
+		},
+		{
+			name:   "registered value in message and attribute",
+			msg:    "bad password hunter22",
+			attrs:  []any{"url", "https://discord.com/api/webhooks/1/abcdef"},
+			hidden: []string{"hunter22", "abcdef"},
+			kept:   []string{"url=***"},
+		},
+		{
+			name: "short value not registered",
+			msg:  "abc is fine",
+			kept: []string{"abc is fine"},
+		},
+		{
+			name:   "registered value in error",
+			msg:    "send failed",
+			attrs:  []any{"err", errors.New(`Post "https://discord.com/api/webhooks/1/abcdef": timeout`)},
+			hidden: []string{"abcdef"},
+		},
+		{
+			name:   "bearer token",
+			msg:    "request",
+			attrs:  []any{"header", "Authorization: Bearer eyJhbGciOi.J9-x_y"},
+			hidden: []string{"eyJhbGciOi"},
+			kept:   []string{"Bearer ***"},
+		},
+		{
+			name:   "pesel",
+			msg:    "kandydat 02270803628",
+			attrs:  []any{"data", []byte(`{"pesel_number":"02270803628"}`)},
+			hidden: []string{"02270803628"},
+			kept:   []string{"kandydat ***"},
+		},
+		{
+			name:   "struct",
+			msg:    "request",
+			attrs:  []any{"req", request{URL: "https://discord.com/api/webhooks/1/abcdef", Body: "hunter22"}},
+			hidden: []string{"abcdef", "hunter22"},
+		},
+		{
+			name:   "stringer",
+			msg:    "send",
+			attrs:  []any{"target", webhook("https://discord.com/api/webhooks/1/abcdef")},
+			hidden: []string{"abcdef"},
+			kept:   []string{"webhook ***"},
+		},
+		{
+			name:   "group",
+			msg:    "login",
+			attrs:  []any{slog.Group("credential", "username", "jan", "pkk", "12345678901234567890")},
+			hidden: []string{"12345678901234567890"},
+			kept:   []string{"credential.username=jan", "credential.pkk=***"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(Redact(slog.NewTextHandler(&buf, nil)))
+			logger.Info(tt.msg, tt.attrs...)
+			out := buf.String()
+			for _, s := range tt.hidden {
+				if strings.Contains(out, s) {
+					t.Errorf("log contains %q: %s", s, out)
+				}
+			}
+			for _, s := range tt.kept {
+				if !strings.Contains(out, s) {
+					t.Errorf("log lacks %q: %s", s, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactWithAttrsAndRequestID(t *testing.T) {
+	AddSecrets("hunter22")
+	var buf bytes.Buffer
+	logger := slog.New(Redact(slog.NewTextHandler(&buf, nil))).With("secret_key", "k", "note", "hunter22")
+
+	logger.InfoContext(WithRequestID(context.Background(), "cafe0001"), "cycle")
+	out := buf.String()
+	if strings.Contains(out, "hunter22") || !strings.Contains(out, "secret_key=***") {
+		t.Errorf("attributes added with With are not masked: %s", out)
+	}
+	if !strings.Contains(out, "request_id=cafe0001") {
+		t.Errorf("log lacks the request ID: %s", out)
+	}
+}
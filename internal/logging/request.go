@@ -0,0 +1,32 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const requestIDKey = "request_id"
+
+type requestIDContextKey struct{}
+
+// NewRequestID returns a short random ID for one poll cycle.
+func NewRequestID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// WithRequestID returns ctx carrying id, which Redact adds to every record
+// logged with that context.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
@@ -0,0 +1,124 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/kapi1023/word-monitor/internal/notify"
+	"github.com/kapi1023/word-monitor/internal/source"
+)
+
+const defaultFailureThreshold = 3
+
+const scopeLogin = "logowanie"
+
+const maxAlertBody = 300
+
+// escalation sends an error alert once a scope has failed threshold times in a
+// row (or immediately when requested) and a recovery notice when it succeeds again.
+type escalation struct {
+	notifier  *notify.Notifier
+	threshold int
+
+	mu       sync.Mutex
+	failures map[string]int
+	alerted  map[string]bool
+}
+
+func newEscalation(n *notify.Notifier, threshold int) *escalation {
+	e := &escalation{
+		notifier: n,
+		failures: make(map[string]int),
+		alerted:  make(map[string]bool),
+	}
+	e.setThreshold(threshold)
+	return e
+}
+
+func (e *escalation) setThreshold(threshold int) {
+	if threshold <= 0 {
+		threshold = defaultFailureThreshold
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.threshold = threshold
+}
+
+func (e *escalation) Failure(ctx context.Context, scope string, err error, immediate bool) {
+	e.mu.Lock()
+	e.failures[scope]++
+	count := e.failures[scope]
+	send := !e.alerted[scope] && (immediate || count >= e.threshold)
+	if send {
+		e.alerted[scope] = true
+	}
+	e.mu.Unlock()
+	if !send {
+		return
+	}
+
+	msg := fmt.Sprintf(
+		"🚨 **Problem z monitoringiem**\n🔎 Zakres: `%s`\n🏷️ Kategoria: `%s`\n🔁 Kolejne błędy: `%d`\n❗ Błąd: `%s`",
+		scope,
+		errorCategory(err),
+		count,
+		err,
+	)
+	var r *source.Error
+	if errors.As(err, &r) && r.StatusCode != 0 {
+		msg += fmt.Sprintf("\n🌐 Odpowiedź: `%d` (%s)", r.StatusCode, r.Tag)
+		if r.Body != "" {
+			msg += fmt.Sprintf("\n```%s```", truncate(r.Body, maxAlertBody))
+		}
+	}
+	if err := e.notifier.SendContext(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "Błąd wysyłki alertu", "scope", scope, "err", err)
+	}
+}
+
+func (e *escalation) Success(ctx context.Context, scope string) {
+	e.mu.Lock()
+	count := e.failures[scope]
+	alerted := e.alerted[scope]
+	delete(e.failures, scope)
+	delete(e.alerted, scope)
+	e.mu.Unlock()
+	if !alerted {
+		return
+	}
+
+	msg := fmt.Sprintf("✅ **Monitoring działa ponownie**\n🔎 Zakres: `%s`\n🔁 Po błędach: `%d`", scope, count)
+	if err := e.notifier.SendContext(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "Błąd wysyłki powiadomienia o powrocie", "scope", scope, "err", err)
+	}
+}
+
+func errorCategory(err error) string {
+	switch source.KindOf(err) {
+	case source.KindAuth:
+		return "logowanie / autoryzacja"
+	case source.KindRateLimited:
+		return "limit zapytań"
+	case source.KindMaintenance:
+		return "źródło niedostępne"
+	case source.KindSchema:
+		return "nieoczekiwana odpowiedź"
+	case source.KindNetwork:
+		return "sieć"
+	case source.KindRequest:
+		return "api"
+	default:
+		return "inne"
+	}
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
@@ -1,113 +1,181 @@
 package monitor
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"strconv"
 	"time"
 
-	"github.com/kapi1023/word-monitor/internal/cache"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/kapi1023/word-monitor/internal/config"
-	"github.com/kapi1023/word-monitor/internal/infocar"
+	"github.com/kapi1023/word-monitor/internal/notify"
+	"github.com/kapi1023/word-monitor/internal/source"
 	"github.com/kapi1023/word-monitor/internal/state"
-	"github.com/kapi1023/word-monitor/internal/webhook"
+	"github.com/kapi1023/word-monitor/internal/tracing"
 )
 
-func Check(cfg *config.Config, i *infocar.InfocarClient, storage *state.Storage, c *cache.Cache[infocar.Word]) (bool, string, error) {
+// Check reads the target's schedule from src and notifies about slots that
+// were not available before. center describes the target's WORD.
+func Check(ctx context.Context, cfg *config.Config, target config.WORD, src source.SlotSource, center source.Center, storage state.Store, n *notify.Notifier) (found bool, _ string, err error) {
+	ctx, span := tracer.Start(ctx, "monitor.Check", trace.WithAttributes(
+		attribute.String("source", src.Name()),
+		attribute.String("word.id", target.WordId),
+		attribute.String("word.category", target.Category),
+	))
+	defer func() {
+		span.SetAttributes(attribute.Bool("found", found))
+		tracing.End(span, err)
+	}()
+
 	now := time.Now()
-	end := now.Add(time.Duration(cfg.Word.MaxDays) * 24 * time.Hour)
+	end := now.Add(time.Duration(target.MaxDays) * 24 * time.Hour)
 
-	schedule, err := i.GetExamSchedule(cfg.Word.Category, cfg.Word.WordId, now, end)
+	hours, err := src.Schedule(ctx, target.WordId, target.Category, now, end)
 	if err != nil {
 		return false, "", err
 	}
 
-	key := state.Key(cfg.Word.WordId, cfg.Word.Category)
-	var messages []string
-	var word infocar.Word
-	wordId, _ := strconv.Atoi(cfg.Word.WordId)
-	word, err = i.GetWordById(c, wordId)
-	if err != nil {
-		slog.Warn("Nie udało się pobrać danych WORD", "id", cfg.Word.WordId, "error", err)
-	}
+	key := state.Key(target.WordId, target.Category)
+	suppress := time.Duration(cfg.Monitor.SuppressWindow) * time.Minute
+	present := make(map[string]bool)
+	var alerts []slotAlert
 
-	for _, day := range schedule.Schedule.ScheduledDays {
-		examDate, err := time.Parse("2006-01-02", day.Day)
+	for _, hour := range hours {
+		examDate, err := time.Parse("2006-01-02", hour.Day)
 		if err != nil || examDate.After(end) {
 			continue
 		}
-		for _, hour := range day.ScheduledHours {
-			if len(hour.PracticeExams) == 0 && len(hour.TheoryExams) == 0 {
-				continue
+		var practice, theory []source.Exam
+		for _, e := range hour.Exams {
+			switch {
+			case e.Type == source.ExamPractice && cfg.Monitor.PracticeExams && passes(cfg.Filter, e):
+				practice = append(practice, e)
+			case e.Type == source.ExamTheory && cfg.Monitor.TheoryExams && passes(cfg.Filter, e):
+				theory = append(theory, e)
 			}
-			hasPractice := cfg.Monitor.PracticeExams && len(hour.PracticeExams) > 0
-			hasTheory := cfg.Monitor.TheoryExams && len(hour.TheoryExams) > 0
+		}
+		hasPractice, hasTheory := len(practice) > 0, len(theory) > 0
 
-			if !hasPractice && !hasTheory {
-				continue
-			}
+		if !hasPractice && !hasTheory {
+			continue
+		}
 
-			if storage.Exists(key, day.Day, hour.Time) {
-				continue
-			}
+		present[state.SlotID(hour.Day, hour.Time)] = true
+		prev, known, err := storage.Lookup(key, hour.Day, hour.Time)
+		if err != nil {
+			return false, "", err
+		}
+		if known && prev.Active() {
+			continue
+		}
 
-			if hasPractice {
-				msg := fmt.Sprintf(
-					"**Wolny termin egzaminu praktycznego!**\n📅 Data: `%s`\n⏰ Godzina: `%s`\n📍 WORD: `%s (%s)`\n📁 Kategoria: `%s`\n🆔 ID: `%s`\n📂 Dostępne: `%d`",
-					day.Day,
-					hour.Time,
-					word.Name,
-					word.Address,
-					cfg.Word.Category,
-					cfg.Word.WordId,
-					len(hour.PracticeExams),
-				)
-				messages = append(messages, msg)
-			}
+		slot := state.ExamSlot{
+			Day:         hour.Day,
+			Time:        hour.Time,
+			PracticeIDs: examIDs(practice),
+			TheoryIDs:   examIDs(theory),
+			Exams:       stateExams(practice, theory),
+			NotifiedAt:  now,
+		}
 
-			if hasTheory {
-				msg := fmt.Sprintf(
-					"**Wolny termin egzaminu teoretycznego!**\n📅 Data: `%s`\n⏰ Godzina: `%s`\n📍 WORD: `%s (%s)`\n📁 Kategoria: `%s`\n🆔 ID: `%s`\n🤦‍♂️ Dostępne: `%d`",
-					day.Day,
-					hour.Time,
-					word.Name,
-					word.Address,
-					cfg.Word.Category,
-					cfg.Word.WordId,
-					len(hour.TheoryExams),
-				)
-				messages = append(messages, msg)
+		// Without a window a returning slot is never announced again.
+		if known && (suppress == 0 || now.Sub(prev.NotifiedAt) < suppress) {
+			slot.NotifiedAt = prev.NotifiedAt
+			if _, err := storage.Add(key, slot); err != nil {
+				return false, "", err
 			}
+			slog.DebugContext(ctx, "Termin wrócił w oknie tłumienia, pomijam powiadomienie", "data", hour.Day, "godzina", hour.Time)
+			continue
+		}
 
-			var practiceIDs, theoryIDs []string
-			if hasPractice {
-				for _, p := range hour.PracticeExams {
-					practiceIDs = append(practiceIDs, p.ID)
-				}
-			}
-			if hasTheory {
-				for _, t := range hour.TheoryExams {
-					theoryIDs = append(theoryIDs, t.ID)
-				}
-			}
+		// Claim the slot before notifying, so instances sharing the
+		// store do not send the same alert.
+		claimed, err := storage.Add(key, slot)
+		if err != nil {
+			return false, "", err
+		}
+		if !claimed {
+			slog.DebugContext(ctx, "Termin zgłoszony już przez inną instancję", "data", hour.Day, "godzina", hour.Time)
+			continue
+		}
 
-			storage.Add(key, state.ExamSlot{
-				Day:         day.Day,
-				Time:        hour.Time,
-				PracticeIDs: practiceIDs,
-				TheoryIDs:   theoryIDs,
-			})
-			slog.Warn("Znaleziono NOWY termin", "data", day.Day, "godzina", hour.Time)
+		alert := slotAlert{day: hour.Day, time: hour.Time}
+		if hasPractice {
+			msg := fmt.Sprintf(
+				"**Wolny termin egzaminu praktycznego!**\n📅 Data: `%s`\n⏰ Godzina: `%s`\n📍 WORD: `%s (%s)`\n📁 Kategoria: `%s`\n🆔 ID: `%s`\n📂 Dostępne: `%d`",
+				hour.Day,
+				hour.Time,
+				center.Name,
+				center.Address,
+				target.Category,
+				target.WordId,
+				len(practice),
+			)
+			alert.messages = append(alert.messages, msg+examDetails(practice))
 		}
+
+		if hasTheory {
+			msg := fmt.Sprintf(
+				"**Wolny termin egzaminu teoretycznego!**\n📅 Data: `%s`\n⏰ Godzina: `%s`\n📍 WORD: `%s (%s)`\n📁 Kategoria: `%s`\n🆔 ID: `%s`\n🤦‍♂️ Dostępne: `%d`",
+				hour.Day,
+				hour.Time,
+				center.Name,
+				center.Address,
+				target.Category,
+				target.WordId,
+				len(theory),
+			)
+			alert.messages = append(alert.messages, msg+examDetails(theory))
+		}
+		alerts = append(alerts, alert)
+
+		slog.WarnContext(ctx, "Znaleziono NOWY termin", "data", hour.Day, "godzina", hour.Time)
+	}
+
+	gone, err := storage.Sweep(key, present, now)
+	if err != nil {
+		return false, "", err
+	}
+	for _, gone := range gone {
+		slog.InfoContext(ctx, "Termin zniknął", "data", gone.Day, "godzina", gone.Time)
 	}
 
-	if len(messages) > 0 {
-		for _, msg := range messages {
-			webhook.Send(cfg.Webhook.DiscordURL, msg)
-			time.Sleep(250 * time.Millisecond)
+	var sendErr error
+	for _, alert := range alerts {
+		if err := sendAll(ctx, n, alert.messages); err != nil {
+			// The slot is claimed but nobody was told; forget it so the
+			// next cycle announces it again.
+			slog.ErrorContext(ctx, "Błąd wysyłki powiadomienia o terminie", "data", alert.day, "godzina", alert.time, "err", err)
+			if rerr := storage.Release(key, alert.day, alert.time); rerr != nil {
+				slog.ErrorContext(ctx, "Nie udało się zwolnić terminu", "data", alert.day, "godzina", alert.time, "err", rerr)
+			}
+			sendErr = errors.Join(sendErr, err)
+			continue
 		}
-		return true, "", nil
+		found = true
 	}
+	if sendErr != nil {
+		return found, "", fmt.Errorf("wysyłka powiadomień: %w", sendErr)
+	}
+	return found, "", nil
+}
 
-	return false, "", nil
+// slotAlert holds the messages about one newly claimed slot.
+type slotAlert struct {
+	day, time string
+	messages  []string
+}
+
+func sendAll(ctx context.Context, n *notify.Notifier, messages []string) error {
+	for _, msg := range messages {
+		err := n.SendContext(ctx, msg)
+		time.Sleep(250 * time.Millisecond)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,351 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/catalog"
+	"github.com/kapi1023/word-monitor/internal/config"
+	"github.com/kapi1023/word-monitor/internal/infocar"
+	"github.com/kapi1023/word-monitor/internal/infocar/fakeinfocar"
+	"github.com/kapi1023/word-monitor/internal/notify"
+	"github.com/kapi1023/word-monitor/internal/source"
+	"github.com/kapi1023/word-monitor/internal/source/infocarsource"
+	"github.com/kapi1023/word-monitor/internal/state"
+)
+
+// discord records the messages posted to a fake Discord webhook.
+type discord struct {
+	*httptest.Server
+	mu       sync.Mutex
+	messages []string
+	failing  bool
+}
+
+func newDiscord(t *testing.T) *discord {
+	t.Helper()
+	d := &discord{}
+	d.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Content string `json:"content"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.failing {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		d.messages = append(d.messages, payload.Content)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(d.Close)
+	return d
+}
+
+// fail makes the webhook reject messages until called with false.
+func (d *discord) fail(failing bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.failing = failing
+}
+
+func (d *discord) Messages() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.messages...)
+}
+
+type fixture struct {
+	infocar *fakeinfocar.Server
+	discord *discord
+	cfg     *config.Config
+	target  config.WORD
+	client  *infocar.InfocarClient
+	words   *catalog.Catalog
+	source  *infocarsource.Source
+	storage state.Store
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	fake := fakeinfocar.New("user", "secret")
+	t.Cleanup(fake.Close)
+	fake.SetWords(infocar.AvailableWords{
+		Provinces: []infocar.Provinces{{ID: 7, Name: "mazowieckie"}},
+		Words:     []infocar.Word{{ID: 1, Name: "WORD Warszawa", Address: "ul. Odlewnicza 8", ProvinceID: 7}},
+	})
+	fake.SetSchedule(schedule(time.Now().AddDate(0, 0, 3).Format("2006-01-02")))
+
+	dir := t.TempDir()
+	storage, err := state.NewFile(filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatalf("NewFile: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+
+	d := newDiscord(t)
+	target := config.WORD{WordId: "1", Category: "B", MaxDays: 30}
+	cfg := &config.Config{
+		Credential: config.Credential{Username: "user", Password: "secret"},
+		Webhook:    config.Webhook{DiscordURL: d.URL},
+		Monitor:    config.Monitor{BaseUrl: fake.URL, Interval: 60, PracticeExams: true, TheoryExams: true},
+		Targets:    []config.WORD{target},
+	}
+	client := infocar.NewCLient(fake.URL, &http.Client{Timeout: 5 * time.Second})
+	words := catalog.New(filepath.Join(dir, "catalog.json"), client)
+	return &fixture{
+		infocar: fake,
+		discord: d,
+		cfg:     cfg,
+		target:  target,
+		client:  client,
+		words:   words,
+		source:  infocarsource.New(client, words, time.Hour),
+		storage: storage,
+	}
+}
+
+func schedule(day string) infocar.ExamScheduleResponse {
+	return infocar.ExamScheduleResponse{
+		Category:       "B",
+		OrganizationId: "1",
+		Schedule: infocar.Schedule{ScheduledDays: []infocar.ScheduleDays{{
+			Day: day,
+			ScheduledHours: []infocar.ScheduledHours{{
+				Time:          "08:00:00",
+				PracticeExams: []infocar.PracticeExams{{ID: "p1", Places: 1, Date: day + "T08:00:00", Amount: 200}},
+				TheoryExams:   []infocar.TheoryExams{{ID: "t1", Places: 5, Date: day + "T08:00:00", Amount: 50}},
+			}},
+		}}},
+	}
+}
+
+// check logs in when needed and runs one Check of the fixture's target.
+func (f *fixture) check(t *testing.T) bool {
+	t.Helper()
+	ctx := context.Background()
+	if err := f.source.Login(ctx, "user", "secret"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	center := source.Center{ID: "1", Name: "WORD Warszawa", Address: "ul. Odlewnicza 8"}
+	found, _, err := Check(ctx, f.cfg, f.target, f.source, center, f.storage, notify.New(f.cfg.Webhook.DiscordURL, config.QuietHours{}))
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	return found
+}
+
+func TestCheckNotifiesNewSlot(t *testing.T) {
+	f := newFixture(t)
+
+	if !f.check(t) {
+		t.Fatal("Check found = false, want true")
+	}
+	msgs := f.discord.Messages()
+	if len(msgs) != 2 {
+		t.Fatalf("sent %d messages, want practice and theory: %q", len(msgs), msgs)
+	}
+	if !strings.Contains(msgs[0], "praktycznego") || !strings.Contains(msgs[1], "teoretycznego") {
+		t.Errorf("messages = %q, want practice then theory", msgs)
+	}
+	for _, msg := range msgs {
+		if !strings.Contains(msg, "WORD Warszawa") || !strings.Contains(msg, "08:00:00") {
+			t.Errorf("message %q lacks the center or time", msg)
+		}
+	}
+}
+
+func TestCheckDedupesKnownSlot(t *testing.T) {
+	f := newFixture(t)
+
+	f.check(t)
+	if f.check(t) {
+		t.Error("second Check found = true, want false for an already notified slot")
+	}
+	if got := len(f.discord.Messages()); got != 2 {
+		t.Errorf("sent %d messages, want 2 from the first Check only", got)
+	}
+}
+
+func TestCheckSuppressesReturningSlot(t *testing.T) {
+	day := time.Now().AddDate(0, 0, 3).Format("2006-01-02")
+	tests := []struct {
+		name     string
+		suppress int
+		age      time.Duration
+		want     int
+	}{
+		{"no window", 0, 48 * time.Hour, 2},
+		{"within window", 60, 0, 2},
+		{"after window", 60, 2 * time.Hour, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.cfg.Monitor.SuppressWindow = tt.suppress
+
+			f.check(t)
+			f.infocar.SetSchedule(infocar.ExamScheduleResponse{Category: "B", OrganizationId: "1"})
+			f.check(t)
+			key := state.Key("1", "B")
+			slots, err := f.storage.Get(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range slots {
+				slots[i].NotifiedAt = slots[i].NotifiedAt.Add(-tt.age)
+			}
+			if err := f.storage.Put(key, slots); err != nil {
+				t.Fatal(err)
+			}
+			f.infocar.SetSchedule(schedule(day))
+			f.check(t)
+
+			if got := len(f.discord.Messages()); got != tt.want {
+				t.Errorf("sent %d messages, want %d", got, tt.want)
+			}
+			slot, ok, err := f.storage.Lookup(key, day, "08:00:00")
+			if err != nil || !ok || !slot.Active() {
+				t.Errorf("Lookup = %+v, %t, %v; want an active slot", slot, ok, err)
+			}
+		})
+	}
+}
+
+func TestCheckRetriesFailedNotification(t *testing.T) {
+	f := newFixture(t)
+	f.discord.fail(true)
+
+	center := source.Center{ID: "1", Name: "WORD Warszawa"}
+	n := notify.New(f.cfg.Webhook.DiscordURL, config.QuietHours{})
+	if err := f.source.Login(context.Background(), "user", "secret"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	found, _, err := Check(context.Background(), f.cfg, f.target, f.source, center, f.storage, n)
+	if err == nil || found {
+		t.Fatalf("Check = %t, %v; want the send error", found, err)
+	}
+	day := time.Now().AddDate(0, 0, 3).Format("2006-01-02")
+	if _, ok, _ := f.storage.Lookup(state.Key("1", "B"), day, "08:00:00"); ok {
+		t.Error("slot stays claimed after the notification failed")
+	}
+
+	f.discord.fail(false)
+	if !f.check(t) {
+		t.Error("Check after the webhook recovered found = false, want the slot announced")
+	}
+	if got := len(f.discord.Messages()); got != 2 {
+		t.Errorf("sent %d messages, want 2", got)
+	}
+}
+
+func TestCheckReturnsAuthError(t *testing.T) {
+	f := newFixture(t)
+	f.check(t)
+
+	f.infocar.RevokeTokens()
+	center := source.Center{ID: "1"}
+	_, _, err := Check(context.Background(), f.cfg, f.target, f.source, center, f.storage, notify.New(f.cfg.Webhook.DiscordURL, config.QuietHours{}))
+	if !errors.Is(err, source.ErrAuth) {
+		t.Fatalf("Check error = %v, want source.ErrAuth", err)
+	}
+}
+
+func TestPollerLogsInAgainAfterExpiry(t *testing.T) {
+	f := newFixture(t)
+	p := NewPoller(f.cfg, f.source, f.storage, f.words)
+	done := make(chan error, 1)
+	go func() { done <- p.Run() }()
+	t.Cleanup(p.Stop)
+
+	waitFor(t, "first schedule request", func() bool { return len(f.infocar.ScheduleRequests()) >= 1 })
+	if got := f.infocar.Logins(); got != 1 {
+		t.Fatalf("Logins() = %d before expiry, want 1", got)
+	}
+
+	f.infocar.RevokeTokens()
+	requests := len(f.infocar.ScheduleRequests())
+	p.CheckNow()
+	waitFor(t, "re-login", func() bool { return f.infocar.Logins() == 2 })
+	// The cycle is repeated right after the new login. The fake only records
+	// authorized requests.
+	waitFor(t, "schedule request after re-login", func() bool { return len(f.infocar.ScheduleRequests()) > requests })
+
+	// The configuration can be replaced while the poller runs.
+	reconfigured := make(chan struct{})
+	go func() {
+		p.Reconfigure(p.Config())
+		close(reconfigured)
+	}()
+	select {
+	case <-reconfigured:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Reconfigure blocked")
+	}
+
+	if ts := p.Stats().Targets[state.Key("1", "B")]; ts.Errors != 0 {
+		t.Errorf("target errors = %d, want 0: %s", ts.Errors, ts.LastError)
+	}
+	p.Stop()
+	if err := <-done; !errors.Is(err, ErrStopped) {
+		t.Errorf("Run() = %v, want ErrStopped", err)
+	}
+}
+
+func TestPollerEditsConfigCopy(t *testing.T) {
+	f := newFixture(t)
+	p := NewPoller(f.cfg, f.source, f.storage, f.words)
+	done := make(chan error, 1)
+	go func() { done <- p.Run() }()
+	t.Cleanup(p.Stop)
+	waitFor(t, "first schedule request", func() bool { return len(f.infocar.ScheduleRequests()) >= 1 })
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			_ = p.Config()
+			_ = p.Targets()
+		}
+	}()
+	p.Exclusive(func(cfg *config.Config) {
+		cfg.Monitor.Interval = 120
+		cfg.Targets[0].MaxDays = 10
+	})
+	p.AddTarget(config.WORD{WordId: "2", Category: "A", MaxDays: 30})
+	wg.Wait()
+
+	cfg := p.Config()
+	if cfg.Monitor.Interval != 120 || len(cfg.Targets) != 2 || cfg.Targets[0].MaxDays != 10 {
+		t.Errorf("Config() = interval %d, targets %+v; want the edits applied", cfg.Monitor.Interval, cfg.Targets)
+	}
+	if f.cfg.Monitor.Interval != 60 || len(f.cfg.Targets) != 1 || f.cfg.Targets[0].MaxDays != 30 {
+		t.Errorf("the config passed to NewPoller changed: %+v", f.cfg.Targets)
+	}
+	p.Stop()
+	<-done
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
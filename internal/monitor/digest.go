@@ -0,0 +1,73 @@
+package monitor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/config"
+	"github.com/kapi1023/word-monitor/internal/source"
+	"github.com/kapi1023/word-monitor/internal/state"
+)
+
+const maxDigestSlots = 10
+
+// Digest summarises a target's slots and poll statistics for the period
+// starting at since.
+func Digest(target config.WORD, center source.Center, slots []state.ExamSlot, stats TargetStats, uptime time.Duration, since time.Time) string {
+	var seen int
+	var earliest *state.ExamSlot
+	var cameAndWent []state.ExamSlot
+	for i, slot := range slots {
+		appeared := !slot.NotifiedAt.Before(since)
+		disappeared := !slot.Active() && !slot.GoneAt.Before(since)
+		if slot.Active() || appeared || disappeared {
+			seen++
+		}
+		if appeared && disappeared {
+			cameAndWent = append(cameAndWent, slot)
+		}
+		if slot.Active() && (earliest == nil || state.SlotID(slot.Day, slot.Time) < state.SlotID(earliest.Day, earliest.Time)) {
+			earliest = &slots[i]
+		}
+	}
+	sort.Slice(cameAndWent, func(a, b int) bool {
+		return state.SlotID(cameAndWent[a].Day, cameAndWent[a].Time) < state.SlotID(cameAndWent[b].Day, cameAndWent[b].Time)
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "**Dzienne podsumowanie**\n📍 WORD: `%s (%s)`\n📁 Kategoria: `%s`\n", center.Name, target.WordId, target.Category)
+	if stats.Offline {
+		b.WriteString("⛔ WORD oznaczony jako offline, sprawdzanie wstrzymane\n")
+	}
+	fmt.Fprintf(&b, "📊 Widziane terminy: `%d`\n", seen)
+	if earliest != nil {
+		fmt.Fprintf(&b, "📅 Najwcześniejszy dostępny: `%s %s`", earliest.Day, earliest.Time)
+		if len(earliest.Exams) > 0 {
+			cheapest, places := earliest.Exams[0].Amount, 0
+			for _, e := range earliest.Exams {
+				cheapest = min(cheapest, e.Amount)
+				places += e.Places
+			}
+			fmt.Fprintf(&b, " · od `%d zł` · miejsca `%d`", cheapest, places)
+		}
+		b.WriteString("\n")
+	} else {
+		b.WriteString("📅 Najwcześniejszy dostępny: `brak`\n")
+	}
+	fmt.Fprintf(&b, "🔁 Pojawiły się i zniknęły: `%d`\n", len(cameAndWent))
+	for i, slot := range cameAndWent {
+		if i == maxDigestSlots {
+			fmt.Fprintf(&b, "  …i %d więcej\n", len(cameAndWent)-maxDigestSlots)
+			break
+		}
+		fmt.Fprintf(&b, "  • `%s %s`\n", slot.Day, slot.Time)
+	}
+	fmt.Fprintf(&b, "⏱️ Czas działania: `%s`\n", uptime.Truncate(time.Minute))
+	fmt.Fprintf(&b, "✅ Sprawdzenia: `%d`, ❌ Błędy: `%d`", stats.Checks, stats.Errors)
+	if stats.Errors > 0 && stats.LastError != "" {
+		fmt.Fprintf(&b, "\n⚠️ Ostatni błąd: `%s`", stats.LastError)
+	}
+	return b.String()
+}
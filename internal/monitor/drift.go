@@ -0,0 +1,48 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// handleDrift saves the payload that did not match the expected schema and
+// sends a warning the first time a given difference is seen. Later payloads
+// with the same difference are only logged, so a lasting change does not fill
+// the disk.
+func (p *Poller) handleDrift(ctx context.Context, tag, drift string, raw []byte) {
+	signature := tag + "|" + drift
+	p.mu.Lock()
+	seen := p.drifts[signature]
+	p.drifts[signature] = true
+	seq := len(p.drifts)
+	dir := p.cfg.DriftDir()
+	p.mu.Unlock()
+	if seen {
+		slog.DebugContext(ctx, "Znana zmiana schematu odpowiedzi info-car", "tag", tag, "drift", drift)
+		return
+	}
+
+	// The sequence number keeps dumps made within the same second apart.
+	name := fmt.Sprintf("%s-%03d-%s.json", time.Now().Format("20060102T150405"), seq, tag)
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		slog.ErrorContext(ctx, "Nie udało się zapisać odpowiedzi info-car", "err", err)
+		path = ""
+	} else if err := os.WriteFile(path, raw, 0644); err != nil {
+		slog.ErrorContext(ctx, "Nie udało się zapisać odpowiedzi info-car", "err", err)
+		path = ""
+	}
+	slog.WarnContext(ctx, "Zmiana schematu odpowiedzi info-car", "tag", tag, "drift", drift, "saved", path)
+
+	msg := fmt.Sprintf("⚠️ **Zmiana formatu odpowiedzi info-car**\n🏷️ Zapytanie: `%s`\n🧩 %s", tag, truncate(drift, maxAlertBody))
+	if path != "" {
+		msg += fmt.Sprintf("\n💾 Zapisano: `%s`", path)
+	}
+	if err := p.errors.SendContext(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "Błąd wysyłki ostrzeżenia o zmianie schematu", "err", err)
+	}
+}
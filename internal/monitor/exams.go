@@ -0,0 +1,88 @@
+package monitor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kapi1023/word-monitor/internal/catalog"
+	"github.com/kapi1023/word-monitor/internal/config"
+	"github.com/kapi1023/word-monitor/internal/source"
+	"github.com/kapi1023/word-monitor/internal/state"
+)
+
+const maxListedExams = 5
+
+// passes reports whether the exam satisfies the configured filter rules.
+// Text rules ignore case and Polish diacritics.
+func passes(f config.Filter, e source.Exam) bool {
+	if f.MinPlaces > 0 && e.Places < f.MinPlaces {
+		return false
+	}
+	if f.MaxAmount > 0 && e.Amount > f.MaxAmount {
+		return false
+	}
+	info := catalog.Fold(e.Info)
+	if f.InfoContains != "" && !strings.Contains(info, catalog.Fold(f.InfoContains)) {
+		return false
+	}
+	if f.InfoExcludes != "" && strings.Contains(info, catalog.Fold(f.InfoExcludes)) {
+		return false
+	}
+	return true
+}
+
+func examIDs(exams []source.Exam) []string {
+	var ids []string
+	for _, e := range exams {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func stateExams(groups ...[]source.Exam) []state.Exam {
+	var exams []state.Exam
+	for _, group := range groups {
+		for _, e := range group {
+			exams = append(exams, state.Exam{ID: e.ID, Type: e.Type, Date: e.Date, Places: e.Places, Amount: e.Amount, Info: e.Info})
+		}
+	}
+	return exams
+}
+
+// examDetails describes prices, places and notes of the exams offered in one
+// schedule hour.
+func examDetails(exams []source.Exam) string {
+	var b strings.Builder
+	places, minAmount, maxAmount := 0, exams[0].Amount, exams[0].Amount
+	for _, e := range exams {
+		places += e.Places
+		minAmount = min(minAmount, e.Amount)
+		maxAmount = max(maxAmount, e.Amount)
+	}
+	if minAmount == maxAmount {
+		fmt.Fprintf(&b, "\n💰 Cena: `%d zł`", minAmount)
+	} else {
+		fmt.Fprintf(&b, "\n💰 Cena: `%d–%d zł`", minAmount, maxAmount)
+	}
+	fmt.Fprintf(&b, "\n👥 Miejsca: `%d`", places)
+
+	for i, e := range exams {
+		if i == maxListedExams {
+			fmt.Fprintf(&b, "\n  …i %d więcej", len(exams)-maxListedExams)
+			break
+		}
+		fmt.Fprintf(&b, "\n  • `%s` · `%d zł` · miejsca `%d`", examDateTime(e), e.Amount, e.Places)
+		if e.Info != "" {
+			fmt.Fprintf(&b, " · ℹ️ %s", truncate(e.Info, 100))
+		}
+	}
+	return b.String()
+}
+
+func examDateTime(e source.Exam) string {
+	t, err := e.Time()
+	if err != nil {
+		return e.Date
+	}
+	return t.Format("2006-01-02 15:04")
+}
@@ -0,0 +1,716 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kapi1023/word-monitor/internal/calendar"
+	"github.com/kapi1023/word-monitor/internal/catalog"
+	"github.com/kapi1023/word-monitor/internal/config"
+	"github.com/kapi1023/word-monitor/internal/heartbeat"
+	"github.com/kapi1023/word-monitor/internal/leader"
+	"github.com/kapi1023/word-monitor/internal/logging"
+	"github.com/kapi1023/word-monitor/internal/notify"
+	"github.com/kapi1023/word-monitor/internal/source"
+	"github.com/kapi1023/word-monitor/internal/state"
+	"github.com/kapi1023/word-monitor/internal/tracing"
+)
+
+var tracer = otel.Tracer("github.com/kapi1023/word-monitor/internal/monitor")
+
+const (
+	maxLoginBackoff = 30 * time.Minute
+	maxEvents       = 50
+	// While a watched center is offline the catalog is refreshed this often
+	// instead of once a day, so its return is noticed quickly.
+	offlineRecheck = 15 * time.Minute
+)
+
+type TargetStats struct {
+	Offline     bool
+	Checks      int
+	Errors      int
+	LastSuccess time.Time
+	LastError   string
+}
+
+type Stats struct {
+	Started     time.Time
+	LastSuccess time.Time
+	Phase       string
+	NextCheck   time.Time
+	Paused      bool
+	Targets     map[string]TargetStats
+}
+
+const (
+	EventNotification = "powiadomienie"
+	EventError        = "błąd"
+)
+
+type Event struct {
+	Time time.Time
+	Kind string
+	Text string
+}
+
+// ErrStopped is returned by Run after Stop was called.
+var ErrStopped = errors.New("monitoring zatrzymany")
+
+// errLoginRequired ends a cycle whose session expired, so Run can log in
+// again without holding the cycle lock.
+var errLoginRequired = errors.New("sesja wygasła")
+
+type Poller struct {
+	cfg        *config.Config
+	source     source.SlotSource
+	storage    state.Store
+	catalog    *catalog.Catalog
+	alerts     *notify.Notifier
+	errors     *notify.Notifier
+	escalation *escalation
+	heartbeat  *heartbeat.Heartbeat
+	elector    *leader.Elector
+
+	mu         sync.Mutex
+	stats      Stats
+	digestBase map[string]TargetStats
+	lastDigest time.Time
+	offline    map[string]bool
+	recheckAt  time.Time
+	drifts     map[string]bool
+	events     []Event
+	paused     bool
+	standby    bool
+	// relogged is set for the cycle following a re-login; a session that
+	// expires again then counts as an ordinary error. Only touched by the
+	// goroutine running Run.
+	relogged bool
+	// retry picks the wait after a cycle; its interval follows the config at
+	// the start of each cycle. Only touched by the goroutine running Run.
+	retry retryPolicy
+
+	// cycle is held while a poll cycle runs, so configuration can be edited
+	// between cycles.
+	cycle    sync.Mutex
+	stop     chan struct{}
+	stopOnce sync.Once
+	wake     chan struct{}
+	// ctx is cancelled by Stop and aborts requests to the slot source.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewPoller polls src for the configured targets. words is the center
+// catalog kept fresh while monitoring and used for the calendar export.
+func NewPoller(cfg *config.Config, src source.SlotSource, storage state.Store, words *catalog.Catalog) *Poller {
+	own := cfg.Clone()
+	errs := notify.New(errorURL(cfg), cfg.Webhook.ErrorQuietHours)
+	p := &Poller{
+		cfg:        &own,
+		source:     src,
+		storage:    storage,
+		catalog:    words,
+		alerts:     notify.New(cfg.Webhook.DiscordURL, cfg.Webhook.QuietHours),
+		errors:     errs,
+		escalation: newEscalation(errs, cfg.Monitor.FailureThreshold),
+		heartbeat:  heartbeat.New(cfg.Heartbeat.URL),
+		stats: Stats{
+			Targets: make(map[string]TargetStats),
+		},
+		digestBase: make(map[string]TargetStats),
+		offline:    make(map[string]bool),
+		drifts:     make(map[string]bool),
+		stop:       make(chan struct{}),
+		wake:       make(chan struct{}, 1),
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	p.retry = retryPolicy{interval: p.interval()}
+	if r, ok := src.(source.DriftReporter); ok {
+		r.OnDrift(p.handleDrift)
+	}
+	p.alerts.OnSend(p.notified)
+	errs.OnSend(p.notified)
+	return p
+}
+
+// errorURL is the webhook for errors, which falls back to the one for slots.
+func errorURL(cfg *config.Config) string {
+	if cfg.Webhook.DiscordErrorURL != "" {
+		return cfg.Webhook.DiscordErrorURL
+	}
+	return cfg.Webhook.DiscordURL
+}
+
+func (p *Poller) notified(message string, queued bool) {
+	if queued {
+		message = "(godziny ciszy) " + message
+	}
+	p.addEvent(EventNotification, message)
+}
+
+func (p *Poller) addEvent(kind, text string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, Event{Time: time.Now(), Kind: kind, Text: text})
+	if len(p.events) > maxEvents {
+		p.events = p.events[len(p.events)-maxEvents:]
+	}
+}
+
+// Events returns the most recent notifications and errors, oldest first.
+func (p *Poller) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Event(nil), p.events...)
+}
+
+// Targets returns a copy of the targets polled in the next cycle.
+func (p *Poller) Targets() []config.WORD {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]config.WORD(nil), p.cfg.WatchTargets()...)
+}
+
+// AddTarget starts watching another target from the next cycle on. A config
+// still using the single word section is converted to the targets list.
+func (p *Poller) AddTarget(target config.WORD) {
+	p.Exclusive(func(cfg *config.Config) {
+		if len(cfg.Targets) == 0 && cfg.Word.WordId != "" {
+			cfg.Targets = []config.WORD{cfg.Word}
+			cfg.Word = config.WORD{}
+		}
+		for _, t := range cfg.Targets {
+			if t.WordId == target.WordId && t.Category == target.Category {
+				return
+			}
+		}
+		cfg.Targets = append(cfg.Targets, target)
+	})
+}
+
+func (p *Poller) Pause() {
+	p.mu.Lock()
+	p.paused = true
+	p.mu.Unlock()
+	p.CheckNow()
+}
+
+func (p *Poller) Resume() {
+	p.mu.Lock()
+	p.paused = false
+	p.mu.Unlock()
+	p.CheckNow()
+}
+
+func (p *Poller) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+// CheckNow ends the wait before the next cycle.
+func (p *Poller) CheckNow() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stop makes Run return ErrStopped once the current cycle finishes.
+func (p *Poller) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		p.cancel()
+	})
+}
+
+// Exclusive lets fn edit a copy of the configuration between poll cycles and
+// then puts the copy in place. The poller owns its configuration: it is only
+// changed while both the cycle lock and mu are held, so the cycle may read it
+// under the former and other goroutines under the latter.
+func (p *Poller) Exclusive(fn func(cfg *config.Config)) {
+	p.cycle.Lock()
+	defer p.cycle.Unlock()
+	next := p.Config()
+	fn(&next)
+	p.mu.Lock()
+	*p.cfg = next
+	p.mu.Unlock()
+	p.applyConfig()
+}
+
+// Config returns a copy of the configuration the poller currently uses.
+func (p *Poller) Config() config.Config {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cfg.Clone()
+}
+
+// Reconfigure replaces the configuration between poll cycles.
+func (p *Poller) Reconfigure(next config.Config) {
+	p.Exclusive(func(cfg *config.Config) {
+		*cfg = next.Clone()
+	})
+}
+
+// applyConfig makes the webhooks, quiet hours, alert threshold and heartbeat
+// follow an edited config and masks its new secrets in logs. The caller holds
+// the cycle lock.
+func (p *Poller) applyConfig() {
+	logging.AddSecrets(p.cfg.Secrets()...)
+	p.alerts.Reconfigure(p.cfg.Webhook.DiscordURL, p.cfg.Webhook.QuietHours)
+	p.errors.Reconfigure(errorURL(p.cfg), p.cfg.Webhook.ErrorQuietHours)
+	p.escalation.setThreshold(p.cfg.Monitor.FailureThreshold)
+	p.mu.Lock()
+	p.heartbeat = heartbeat.New(p.cfg.Heartbeat.URL)
+	p.mu.Unlock()
+}
+
+func (p *Poller) setPhase(phase string, next time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats.Phase = phase
+	p.stats.NextCheck = next
+}
+
+// wait sleeps for d, returning early when woken and false when stopped.
+func (p *Poller) wait(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-p.stop:
+		return false
+	case <-p.wake:
+		return true
+	case <-t.C:
+		return true
+	}
+}
+
+func (p *Poller) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.stats
+	s.Paused = p.paused
+	s.Targets = make(map[string]TargetStats, len(p.stats.Targets))
+	for k, v := range p.stats.Targets {
+		s.Targets[k] = v
+	}
+	return s
+}
+
+func (p *Poller) Run() error {
+	if len(p.Targets()) == 0 {
+		return errors.New("brak skonfigurowanych WORDów do monitorowania")
+	}
+
+	cfg := p.Config()
+	elector, err := leader.New(cfg.Leader, cfg.State.Redis)
+	if err != nil {
+		return err
+	}
+	if elector != nil {
+		p.elector = elector
+		elector.OnChange(p.leadershipChanged)
+		elector.Start(p.stop)
+		if !p.awaitLeadership() {
+			return ErrStopped
+		}
+	}
+
+	slog.Info("Rozpoczęcie monitoringu...")
+	if !p.login(p.ctx) {
+		return ErrStopped
+	}
+
+	if err := p.catalog.EnsureFresh(p.ctx, cfg.CatalogRefresh()); err != nil {
+		slog.Warn("Katalog WORD niedostępny", "err", err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go p.catalog.Run(cfg.CatalogRefresh(), stop)
+
+	p.mu.Lock()
+	p.stats.Started = time.Now()
+	p.lastDigest = p.stats.Started
+	p.mu.Unlock()
+
+	for {
+		if p.Paused() {
+			p.setPhase("pauza", time.Time{})
+			if !p.wait(time.Hour) {
+				return ErrStopped
+			}
+			continue
+		}
+
+		if !p.leading() {
+			if !p.awaitLeadership() {
+				return ErrStopped
+			}
+			continue
+		}
+
+		p.setPhase("sprawdzanie", time.Time{})
+		errs, relogged, ok := p.pollOnce()
+		if !ok {
+			return ErrStopped
+		}
+		// After a re-login the cycle is repeated right away.
+		p.relogged = relogged
+		if relogged {
+			continue
+		}
+		delay := p.retry.next(errs)
+		p.setPhase("oczekiwanie", time.Now().Add(delay))
+		if !p.wait(delay) {
+			return ErrStopped
+		}
+	}
+}
+
+func (p *Poller) leading() bool {
+	return p.elector == nil || p.elector.Leading()
+}
+
+// awaitLeadership idles as a standby until this instance becomes the leader.
+// It returns false when the poller was stopped.
+func (p *Poller) awaitLeadership() bool {
+	for !p.leading() {
+		p.mu.Lock()
+		p.standby = true
+		p.mu.Unlock()
+		p.setPhase("rezerwa", time.Time{})
+		if !p.wait(time.Hour) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Poller) leadershipChanged(leading bool) {
+	p.mu.Lock()
+	wasStandby := p.standby
+	p.standby = p.standby && !leading
+	p.mu.Unlock()
+
+	id := p.elector.ID()
+	switch {
+	case leading:
+		slog.Info("Instancja została liderem", "id", id)
+		if wasStandby {
+			p.errors.Send(fmt.Sprintf("👑 **Przejęcie monitoringu**\nInstancja `%s` została liderem i wznawia sprawdzanie.", id))
+		}
+	default:
+		slog.Warn("Instancja przechodzi w tryb rezerwy", "id", id)
+		p.addEvent(EventError, "instancja w trybie rezerwy")
+	}
+	p.CheckNow()
+}
+
+// pollOnce runs a cycle and, when the session expired during it, logs in
+// again once the cycle lock is released. Both share the cycle's span and
+// request ID. ok is false when the poller was stopped.
+func (p *Poller) pollOnce() (errs []error, relogged, ok bool) {
+	// Every line logged for this cycle carries the same request ID.
+	id := logging.NewRequestID()
+	ctx, span := tracer.Start(logging.WithRequestID(p.ctx, id), "monitor.cycle",
+		trace.WithAttributes(attribute.String("request_id", id)))
+	defer func() {
+		span.SetAttributes(attribute.Int("errors", len(errs)))
+		span.End()
+	}()
+
+	errs, relogin, ok := p.runCycle(ctx)
+	if !ok || !relogin {
+		return errs, false, ok
+	}
+	slog.InfoContext(ctx, "Token wygasł, ponowne logowanie...")
+	return errs, true, p.login(ctx)
+}
+
+// runCycle polls every target once. It stops early with relogin set when
+// the session expired, and ok is false when the poller was stopped.
+func (p *Poller) runCycle(ctx context.Context) (errs []error, relogin, ok bool) {
+	p.cycle.Lock()
+	defer p.cycle.Unlock()
+	p.retry.interval = p.interval()
+
+	if err := p.alerts.Flush(); err != nil {
+		slog.ErrorContext(ctx, "Błąd wysyłki podsumowania godzin ciszy", "err", err)
+	}
+	if err := p.errors.Flush(); err != nil {
+		slog.ErrorContext(ctx, "Błąd wysyłki podsumowania godzin ciszy", "err", err)
+	}
+	p.recheckOfflineCenters(ctx)
+	centers := p.centers(ctx)
+	targets := p.Targets()
+	for _, target := range targets {
+		center, ok := centers[target.WordId]
+		if !ok {
+			slog.WarnContext(ctx, "Brak WORD w katalogu", "id", target.WordId)
+			center = source.Center{ID: target.WordId}
+		} else if !p.centerOnline(ctx, target, center) {
+			continue
+		}
+		if err := p.check(ctx, target, center); err != nil {
+			if errors.Is(err, ErrStopped) {
+				return errs, false, false
+			}
+			if errors.Is(err, errLoginRequired) {
+				return errs, true, true
+			}
+			errs = append(errs, err)
+			if isRateLimited(err) {
+				break
+			}
+		}
+	}
+	p.beat(ctx, errs)
+	p.sendDigestIfDue(ctx, targets, centers, time.Now())
+	p.exportCalendar(ctx, targets)
+	return errs, false, true
+}
+
+// exportCalendar rewrites the iCalendar file with the slots found so far.
+func (p *Poller) exportCalendar(ctx context.Context, targets []config.WORD) {
+	path := p.cfg.Calendar.File
+	if path == "" {
+		return
+	}
+	data, err := calendar.Build(targets, p.storage, p.catalog, time.Now())
+	if err == nil {
+		err = calendar.WriteFile(path, data)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Błąd zapisu kalendarza", "path", path, "err", err)
+	}
+}
+
+// login retries with exponential backoff until it succeeds or the poller is
+// stopped. Every failed attempt is escalated immediately since it usually
+// needs manual action. Attempts are traced and logged within ctx.
+func (p *Poller) login(ctx context.Context) bool {
+	p.setPhase("logowanie", time.Time{})
+	for attempt := 0; ; attempt++ {
+		attemptCtx, span := tracer.Start(ctx, "monitor.login", trace.WithAttributes(attribute.Int("attempt", attempt+1)))
+		username, password := p.credentials()
+		err := p.source.Login(attemptCtx, username, password)
+		tracing.End(span, err)
+		if err == nil {
+			slog.InfoContext(ctx, "Zalogowano pomyślnie. Start monitoringu...")
+			p.escalation.Success(ctx, scopeLogin)
+			return true
+		}
+		slog.ErrorContext(ctx, "Błąd logowania", "err", err)
+		p.addEvent(EventError, fmt.Sprintf("%s: %v", scopeLogin, err))
+		p.escalation.Failure(ctx, scopeLogin, err, true)
+		p.beat(ctx, []error{fmt.Errorf("%s: %w", scopeLogin, err)})
+
+		backoff := p.interval() << min(attempt, 10)
+		if backoff > maxLoginBackoff {
+			backoff = maxLoginBackoff
+		}
+		p.setPhase("logowanie", time.Now().Add(backoff))
+		if !p.wait(backoff) {
+			return false
+		}
+	}
+}
+
+// credentials reads the login between cycles, since login runs without the
+// cycle lock while the config may be edited.
+func (p *Poller) credentials() (username, password string) {
+	p.cycle.Lock()
+	defer p.cycle.Unlock()
+	return p.cfg.Credential.Username, p.cfg.Credential.Password
+}
+
+// interval reads the poll interval under mu, as login and the wait between
+// cycles run without the cycle lock.
+func (p *Poller) interval() time.Duration {
+	p.mu.Lock()
+	seconds := p.cfg.Monitor.Interval
+	p.mu.Unlock()
+	if seconds <= 0 {
+		return time.Minute
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// centers lists the source's centers by ID. An empty map is returned when
+// they cannot be read, so targets are checked without center details.
+func (p *Poller) centers(ctx context.Context) map[string]source.Center {
+	centers := make(map[string]source.Center)
+	list, err := p.source.Centers(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Nie udało się pobrać listy ośrodków", "source", p.source.Name(), "err", err)
+		return centers
+	}
+	for _, c := range list {
+		centers[c.ID] = c
+	}
+	return centers
+}
+
+func (p *Poller) check(ctx context.Context, target config.WORD, center source.Center) error {
+	scope := fmt.Sprintf("WORD %s kat. %s", target.WordId, target.Category)
+	found, _, err := Check(ctx, p.cfg, target, p.source, center, p.storage, p.alerts)
+	if p.ctx.Err() != nil {
+		return ErrStopped
+	}
+	if errors.Is(err, source.ErrAuth) && !p.relogged {
+		return errLoginRequired
+	}
+	p.record(target, err)
+	if err != nil {
+		slog.ErrorContext(ctx, "Błąd podczas sprawdzania dostępności", "word", target.WordId, "err", err)
+		p.addEvent(EventError, fmt.Sprintf("%s: %v", scope, err))
+		p.escalation.Failure(ctx, scope, err, false)
+		return fmt.Errorf("%s: %w", scope, err)
+	}
+	p.escalation.Success(ctx, scope)
+	if !found {
+		slog.DebugContext(ctx, "Brak dostępnych terminów", "word", target.WordId)
+	}
+	return nil
+}
+
+// centerOnline reports whether the source lists the target's center as
+// online and announces every change of that status.
+func (p *Poller) centerOnline(ctx context.Context, target config.WORD, word source.Center) bool {
+	key := state.Key(target.WordId, target.Category)
+
+	p.mu.Lock()
+	was := p.offline[target.WordId]
+	p.offline[target.WordId] = word.Offline
+	ts := p.stats.Targets[key]
+	ts.Offline = word.Offline
+	p.stats.Targets[key] = ts
+	p.mu.Unlock()
+
+	switch {
+	case word.Offline && !was:
+		slog.WarnContext(ctx, "WORD offline w info-car, wstrzymuję sprawdzanie", "word", target.WordId)
+		p.alerts.SendContext(ctx, fmt.Sprintf("⛔ **WORD offline**\n📍 WORD: `%s (%s)`\nInfo-car oznacza ośrodek jako niedostępny, sprawdzanie wstrzymane.", word.Name, target.WordId))
+	case !word.Offline && was:
+		slog.InfoContext(ctx, "WORD znów dostępny w info-car", "word", target.WordId)
+		p.alerts.SendContext(ctx, fmt.Sprintf("✅ **WORD znów dostępny**\n📍 WORD: `%s (%s)`\nWznawiam sprawdzanie terminów.", word.Name, target.WordId))
+	}
+	return !word.Offline
+}
+
+func (p *Poller) recheckOfflineCenters(ctx context.Context) {
+	p.mu.Lock()
+	var anyOffline bool
+	for _, offline := range p.offline {
+		anyOffline = anyOffline || offline
+	}
+	due := anyOffline && !time.Now().Before(p.recheckAt)
+	if due {
+		p.recheckAt = time.Now().Add(offlineRecheck)
+	}
+	p.mu.Unlock()
+	if !due {
+		return
+	}
+	if err := p.catalog.Refresh(ctx); err != nil {
+		slog.WarnContext(ctx, "Nie udało się odświeżyć katalogu WORD", "err", err)
+	}
+}
+
+func (p *Poller) beat(ctx context.Context, errs []error) {
+	p.mu.Lock()
+	hb := p.heartbeat
+	p.mu.Unlock()
+	if !hb.Enabled() {
+		return
+	}
+	stats := p.Stats()
+	var err error
+	if len(errs) > 0 {
+		err = hb.Fail(stats.LastSuccess, errs)
+	} else {
+		err = hb.Success(stats.LastSuccess, fmt.Sprintf("cele: %d, działa od: %s", len(stats.Targets), stats.Started.Format(time.RFC3339)))
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Błąd wysyłki heartbeat", "err", err)
+	}
+}
+
+func (p *Poller) record(target config.WORD, err error) {
+	key := state.Key(target.WordId, target.Category)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ts := p.stats.Targets[key]
+	ts.Checks++
+	if err != nil {
+		ts.Errors++
+		ts.LastError = err.Error()
+	} else {
+		ts.LastSuccess = time.Now()
+		p.stats.LastSuccess = ts.LastSuccess
+	}
+	p.stats.Targets[key] = ts
+}
+
+func (p *Poller) sendDigestIfDue(ctx context.Context, targets []config.WORD, centers map[string]source.Center, now time.Time) {
+	if p.cfg.Monitor.DigestTime == "" {
+		return
+	}
+	minutes, err := config.ParseClock(p.cfg.Monitor.DigestTime)
+	if err != nil {
+		slog.WarnContext(ctx, "Nieprawidłowa godzina podsumowania", "digest_time", p.cfg.Monitor.DigestTime, "err", err)
+		return
+	}
+	due := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Add(time.Duration(minutes) * time.Minute)
+	p.mu.Lock()
+	since := p.lastDigest
+	if now.Before(due) || !since.Before(due) {
+		p.mu.Unlock()
+		return
+	}
+	p.lastDigest = now
+	uptime := now.Sub(p.stats.Started)
+	period := make(map[string]TargetStats, len(targets))
+	for _, target := range targets {
+		key := state.Key(target.WordId, target.Category)
+		cur, base := p.stats.Targets[key], p.digestBase[key]
+		period[key] = TargetStats{
+			Offline:     cur.Offline,
+			Checks:      cur.Checks - base.Checks,
+			Errors:      cur.Errors - base.Errors,
+			LastSuccess: cur.LastSuccess,
+			LastError:   cur.LastError,
+		}
+		p.digestBase[key] = cur
+	}
+	p.mu.Unlock()
+
+	for _, target := range targets {
+		key := state.Key(target.WordId, target.Category)
+		center, ok := centers[target.WordId]
+		if !ok {
+			center = source.Center{ID: target.WordId}
+		}
+		slots, err := p.storage.Get(key)
+		if err != nil {
+			slog.ErrorContext(ctx, "Błąd odczytu stanu", "word", target.WordId, "err", err)
+			continue
+		}
+		msg := Digest(target, center, slots, period[key], uptime, since)
+		if err := p.alerts.SendContext(ctx, msg); err != nil {
+			slog.ErrorContext(ctx, "Błąd wysyłki dziennego podsumowania", "word", target.WordId, "err", err)
+		}
+	}
+	slog.InfoContext(ctx, "Wysłano dzienne podsumowanie")
+}
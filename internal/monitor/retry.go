@@ -0,0 +1,55 @@
+package monitor
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/source"
+)
+
+const (
+	defaultRateLimitWait  = 5 * time.Minute
+	maxMaintenanceBackoff = 30 * time.Minute
+)
+
+// retryPolicy decides how long to wait before the next cycle: the Retry-After
+// of a rate limit is honoured and maintenance backs off exponentially.
+type retryPolicy struct {
+	interval    time.Duration
+	maintenance int
+}
+
+func (r *retryPolicy) next(errs []error) time.Duration {
+	delay := r.interval
+	var maintenance bool
+	for _, err := range errs {
+		var e *source.Error
+		if !errors.As(err, &e) {
+			continue
+		}
+		switch e.Kind {
+		case source.KindRateLimited:
+			wait := e.RetryAfter
+			if wait <= 0 {
+				wait = defaultRateLimitWait
+			}
+			delay = max(delay, wait)
+		case source.KindMaintenance:
+			maintenance = true
+		}
+	}
+
+	if !maintenance {
+		r.maintenance = 0
+		return delay
+	}
+	r.maintenance++
+	backoff := min(r.interval<<min(r.maintenance, 10), maxMaintenanceBackoff)
+	slog.Info("Źródło terminów niedostępne, wydłużam odstęp między sprawdzeniami", "delay", max(delay, backoff))
+	return max(delay, backoff)
+}
+
+func isRateLimited(err error) bool {
+	return source.KindOf(err) == source.KindRateLimited
+}
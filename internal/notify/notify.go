@@ -0,0 +1,192 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kapi1023/word-monitor/internal/config"
+	"github.com/kapi1023/word-monitor/internal/tracing"
+	"github.com/kapi1023/word-monitor/internal/webhook"
+)
+
+// Discord rejects messages with content longer than 2000 characters.
+const maxMessageLength = 2000
+
+type Notifier struct {
+	url      string
+	quiet    quietHours
+	mu       sync.Mutex
+	pending  []string
+	observer func(message string, queued bool)
+}
+
+func New(url string, q config.QuietHours) *Notifier {
+	n := &Notifier{}
+	n.Reconfigure(url, q)
+	return n
+}
+
+// Reconfigure changes the webhook and quiet hours. Messages queued so far
+// stay queued.
+func (n *Notifier) Reconfigure(url string, q config.QuietHours) {
+	quiet, err := parseQuietHours(q)
+	if err != nil {
+		slog.Warn("Nieprawidłowe godziny ciszy, wyłączam", "start", q.Start, "end", q.End, "err", err)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.url = url
+	n.quiet = quiet
+}
+
+// settings returns the webhook and whether quiet hours are on at now.
+func (n *Notifier) settings(now time.Time) (url string, quiet bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.url, n.quiet.contains(now)
+}
+
+// OnSend registers a function called for every message accepted by Send,
+// with queued set when it was held back for the quiet hours digest.
+func (n *Notifier) OnSend(f func(message string, queued bool)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.observer = f
+}
+
+var tracer = otel.Tracer("github.com/kapi1023/word-monitor/internal/notify")
+
+// Send delivers the message immediately or, during quiet hours, queues it for
+// the digest sent by Flush once quiet hours end.
+func (n *Notifier) Send(message string) error {
+	return n.SendContext(context.Background(), message)
+}
+
+// SendContext is Send recorded as a "notify.Send" span within ctx.
+func (n *Notifier) SendContext(ctx context.Context, message string) (err error) {
+	url, queued := n.settings(time.Now())
+	ctx, span := tracer.Start(ctx, "notify.Send", trace.WithAttributes(attribute.Bool("notify.queued", queued)))
+	defer func() { tracing.End(span, err) }()
+	n.mu.Lock()
+	if queued {
+		n.pending = append(n.pending, message)
+		slog.DebugContext(ctx, "Godziny ciszy, powiadomienie odłożone", "pending", len(n.pending))
+	}
+	observer := n.observer
+	n.mu.Unlock()
+	if observer != nil {
+		observer(message, queued)
+	}
+	if queued {
+		return nil
+	}
+	return webhook.SendContext(ctx, url, message)
+}
+
+// Flush sends the queued messages as a digest when quiet hours are over.
+func (n *Notifier) Flush() error {
+	url, quiet := n.settings(time.Now())
+	if quiet {
+		return nil
+	}
+	n.mu.Lock()
+	pending := n.pending
+	n.pending = nil
+	n.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	header := fmt.Sprintf("**Podsumowanie godzin ciszy** (%d powiadomień)", len(pending))
+	for _, chunk := range digest(header, pending) {
+		if err := webhook.Send(url, chunk.text); err != nil {
+			// Earlier chunks were delivered, only the rest is queued again.
+			n.mu.Lock()
+			n.pending = append(pending[chunk.first:len(pending):len(pending)], n.pending...)
+			n.mu.Unlock()
+			return err
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	return nil
+}
+
+// digestChunk is one webhook message of a digest. first is the index of the
+// first queued message it carries.
+type digestChunk struct {
+	text  string
+	first int
+}
+
+// cut shortens s to at most n bytes without splitting a UTF-8 sequence,
+// which Discord would reject.
+func cut(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func digest(header string, messages []string) []digestChunk {
+	var chunks []digestChunk
+	var first int
+	var b strings.Builder
+	b.WriteString(header)
+	for i, msg := range messages {
+		if len(msg) > maxMessageLength-2 {
+			msg = cut(msg, maxMessageLength-5) + "..."
+		}
+		if b.Len()+len(msg)+2 > maxMessageLength {
+			chunks = append(chunks, digestChunk{text: b.String(), first: first})
+			first = i
+			b.Reset()
+		} else if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(msg)
+	}
+	return append(chunks, digestChunk{text: b.String(), first: first})
+}
+
+type quietHours struct {
+	enabled    bool
+	start, end int
+}
+
+func parseQuietHours(q config.QuietHours) (quietHours, error) {
+	if q.Start == "" && q.End == "" {
+		return quietHours{}, nil
+	}
+	start, err := config.ParseClock(q.Start)
+	if err != nil {
+		return quietHours{}, err
+	}
+	end, err := config.ParseClock(q.End)
+	if err != nil {
+		return quietHours{}, err
+	}
+	return quietHours{enabled: start != end, start: start, end: end}, nil
+}
+
+func (q quietHours) contains(t time.Time) bool {
+	if !q.enabled {
+		return false
+	}
+	m := t.Hour()*60 + t.Minute()
+	if q.start < q.end {
+		return m >= q.start && m < q.end
+	}
+	return m >= q.start || m < q.end
+}
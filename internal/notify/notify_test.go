@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCut(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"Łódź", 10, "Łódź"},
+		{"Łódź", 7, "Łódź"},
+		{"Łódź", 6, "Łód"},
+		{"Łódź", 3, "Ł"},
+		{"Łódź", 1, ""},
+		{"abc", 2, "ab"},
+	}
+	for _, tt := range tests {
+		if got := cut(tt.s, tt.n); got != tt.want {
+			t.Errorf("cut(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestDigestKeepsLongPolishMessagesValid(t *testing.T) {
+	long := strings.Repeat("ż", maxMessageLength)
+	chunks := digest("Podsumowanie", []string{"krótka", long, "ostatnia"})
+	if len(chunks) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(chunks))
+	}
+	for i, c := range chunks {
+		if len(c.text) > maxMessageLength {
+			t.Errorf("chunk %d has %d bytes, want at most %d", i, len(c.text), maxMessageLength)
+		}
+		if !utf8.ValidString(c.text) {
+			t.Errorf("chunk %d is not valid UTF-8", i)
+		}
+	}
+	if !strings.HasSuffix(chunks[1].text, "ż...") {
+		t.Errorf("truncated message ends with %q, want an ellipsis after a whole rune", chunks[1].text[len(chunks[1].text)-8:])
+	}
+	if chunks[1].first != 1 || chunks[2].first != 2 {
+		t.Errorf("chunk firsts = %d, %d; want 1, 2", chunks[1].first, chunks[2].first)
+	}
+}
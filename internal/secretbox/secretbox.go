@@ -0,0 +1,77 @@
+// Package secretbox encrypts small local files, such as the credential
+// vault, with AES-256-GCM under a key derived from a passphrase.
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	KeySize  = 32
+	SaltSize = 16
+)
+
+// ErrDecrypt is returned when data was encrypted with another key or has
+// been modified.
+var ErrDecrypt = errors.New("decryption failed: wrong key or corrupted data")
+
+// NewSalt returns random salt for Key.
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// Key derives an encryption key from passphrase with scrypt.
+func Key(passphrase string, salt []byte) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("empty passphrase")
+	}
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, KeySize)
+}
+
+// Seal encrypts plaintext and returns the nonce followed by the ciphertext.
+// ad is authenticated but not encrypted, it binds the data to its purpose.
+func Seal(key, plaintext, ad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, ad), nil
+}
+
+// Open reverses Seal.
+func Open(key, sealed, ad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, ad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,139 @@
+// Package infocarsource adapts the info-car client and WORD catalog to
+// source.SlotSource.
+package infocarsource
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/catalog"
+	"github.com/kapi1023/word-monitor/internal/infocar"
+	"github.com/kapi1023/word-monitor/internal/source"
+)
+
+type Source struct {
+	client  *infocar.InfocarClient
+	catalog *catalog.Catalog
+	maxAge  time.Duration
+}
+
+// New returns the info-car source. Centers come from the catalog, refreshed
+// when older than maxAge.
+func New(client *infocar.InfocarClient, words *catalog.Catalog, maxAge time.Duration) *Source {
+	return &Source{client: client, catalog: words, maxAge: maxAge}
+}
+
+func (s *Source) Name() string {
+	return "info-car"
+}
+
+// OnDrift reports responses that no longer match the expected schema.
+func (s *Source) OnDrift(f func(ctx context.Context, tag, drift string, raw []byte)) {
+	s.client.OnDrift(func(ctx context.Context, tag string, drift infocar.Drift, raw []byte) {
+		f(ctx, tag, drift.String(), raw)
+	})
+}
+
+// Login reuses the client's session when info-car still accepts it and logs
+// in with the credentials otherwise.
+func (s *Source) Login(ctx context.Context, username, password string) error {
+	if s.client.Resume(ctx) {
+		slog.InfoContext(ctx, "Wznowiono sesję info-car bez ponownego logowania")
+		return nil
+	}
+	return sourceError(s.client.Login(ctx, username, password))
+}
+
+// Centers lists the WORD centers from the catalog, refreshing it first when
+// it is too old.
+func (s *Source) Centers(ctx context.Context) ([]source.Center, error) {
+	if err := s.catalog.EnsureFresh(ctx, s.maxAge); err != nil {
+		return nil, sourceError(err)
+	}
+	words := s.catalog.Words()
+	centers := make([]source.Center, 0, len(words))
+	for _, w := range words {
+		centers = append(centers, Center(w, s.catalog.ProvinceName(w.ProvinceID)))
+	}
+	return centers, nil
+}
+
+func (s *Source) Schedule(ctx context.Context, centerID, category string, from, to time.Time) ([]source.Hour, error) {
+	schedule, err := s.client.GetExamSchedule(ctx, category, centerID, from, to)
+	if err != nil {
+		return nil, sourceError(err)
+	}
+	var hours []source.Hour
+	for _, day := range schedule.Schedule.ScheduledDays {
+		for _, h := range day.ScheduledHours {
+			hour := source.Hour{Day: day.Day, Time: h.Time}
+			for _, p := range h.PracticeExams {
+				hour.Exams = append(hour.Exams, PracticeExam(p))
+			}
+			for _, t := range h.TheoryExams {
+				hour.Exams = append(hour.Exams, TheoryExam(t))
+			}
+			hours = append(hours, hour)
+		}
+	}
+	return hours, nil
+}
+
+func Center(w infocar.Word, province string) source.Center {
+	return source.Center{
+		ID:        strconv.Itoa(w.ID),
+		Name:      w.Name,
+		Address:   w.Address,
+		Region:    province,
+		Latitude:  w.Latitude,
+		Longitude: w.Longitude,
+		Offline:   w.Offline,
+	}
+}
+
+func PracticeExam(p infocar.PracticeExams) source.Exam {
+	return source.Exam{ID: p.ID, Type: source.ExamPractice, Places: p.Places, Date: p.Date, Amount: p.Amount, Info: infocar.InfoText(p.AdditionalInfo)}
+}
+
+func TheoryExam(t infocar.TheoryExams) source.Exam {
+	return source.Exam{ID: t.ID, Type: source.ExamTheory, Places: t.Places, Date: t.Date, Amount: t.Amount, Info: infocar.InfoText(t.AdditionalInfo)}
+}
+
+// sourceError classifies an info-car error as a *source.Error. Errors that
+// are not info-car responses or transport failures are returned as is.
+func sourceError(err error) error {
+	var (
+		authErr     *infocar.AuthError
+		rateLimited *infocar.RateLimitedError
+		down        *infocar.MaintenanceError
+		schemaErr   *infocar.SchemaError
+		networkErr  *infocar.NetworkError
+		requestErr  *infocar.RequestError
+	)
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &authErr):
+		return withResponse(source.KindAuth, err, &authErr.RequestError)
+	case errors.As(err, &rateLimited):
+		e := withResponse(source.KindRateLimited, err, &rateLimited.RequestError)
+		e.RetryAfter = rateLimited.RetryAfter
+		return e
+	case errors.As(err, &down):
+		return withResponse(source.KindMaintenance, err, &down.RequestError)
+	case errors.As(err, &schemaErr):
+		return withResponse(source.KindSchema, err, &schemaErr.RequestError)
+	case errors.As(err, &networkErr):
+		return &source.Error{Kind: source.KindNetwork, Tag: networkErr.Tag, Err: err}
+	case errors.As(err, &requestErr):
+		return withResponse(source.KindRequest, err, requestErr)
+	}
+	return err
+}
+
+func withResponse(kind source.Kind, err error, r *infocar.RequestError) *source.Error {
+	return &source.Error{Kind: kind, StatusCode: r.StatusCode, Tag: r.Tag, Body: r.Body, Err: err}
+}
@@ -0,0 +1,139 @@
+// Package source abstracts the booking systems slots are read from, so the
+// monitor, state, filters and notifiers work with any of them.
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	// The Docker image has no zone database, Location must load anyway.
+	_ "time/tzdata"
+)
+
+const (
+	ExamPractice = "practice"
+	ExamTheory   = "theory"
+)
+
+// Location is the zone of dates reported without one. All WORD centers are in
+// Poland, whatever the zone of the machine running the monitor.
+var Location = mustLoadLocation("Europe/Warsaw")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// ErrAuth marks errors that a new Login should fix, e.g. an expired session.
+var ErrAuth = errors.New("authentication required")
+
+// Kind classifies a source failure, so retries and alerts do not depend on a
+// particular booking system.
+type Kind string
+
+const (
+	KindAuth        Kind = "auth"
+	KindRateLimited Kind = "rate_limited"
+	KindMaintenance Kind = "maintenance"
+	KindSchema      Kind = "schema"
+	KindNetwork     Kind = "network"
+	KindRequest     Kind = "request"
+)
+
+// Error is a classified source failure. StatusCode, Tag and Body describe the
+// response when there was one; RetryAfter is set for KindRateLimited when
+// the source said how long to wait.
+type Error struct {
+	Kind       Kind
+	RetryAfter time.Duration
+	StatusCode int
+	Tag        string
+	Body       string
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is makes an error of KindAuth match ErrAuth.
+func (e *Error) Is(target error) bool {
+	return target == ErrAuth && e.Kind == KindAuth
+}
+
+// KindOf returns the kind of the first Error in err's chain, or "" when
+// there is none.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return ""
+}
+
+// Center is a place offering exams or appointments.
+type Center struct {
+	ID        string
+	Name      string
+	Address   string
+	Region    string
+	Latitude  string
+	Longitude string
+	Offline   bool
+}
+
+// Hour is one scheduled time with the exams offered then. Day is
+// "2006-01-02"; Time is the source's own hour format.
+type Hour struct {
+	Day   string
+	Time  string
+	Exams []Exam
+}
+
+// Exam is one bookable exam or appointment.
+type Exam struct {
+	ID     string
+	Type   string
+	Places int
+	Date   string
+	Amount int
+	Info   string
+}
+
+// Time parses the exam date, which may come with or without a zone. Dates
+// without one are in Location.
+func (e Exam) Time() (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, e.Date, Location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid exam date %q", e.Date)
+}
+
+// SlotSource is a booking system the monitor polls.
+type SlotSource interface {
+	// Name is shown in logs and notifications, e.g. "info-car".
+	Name() string
+	Login(ctx context.Context, username, password string) error
+	Centers(ctx context.Context) ([]Center, error)
+	// Schedule returns the hours with free exams of a center between from
+	// and to. Failures are returned as *Error where the source can classify
+	// them; errors that need a new login match ErrAuth.
+	Schedule(ctx context.Context, centerID, category string, from, to time.Time) ([]Hour, error)
+}
+
+// DriftReporter is implemented by sources that notice when the format of
+// their responses changes. f gets a description of the difference and the
+// raw response.
+type DriftReporter interface {
+	OnDrift(f func(ctx context.Context, tag, drift string, raw []byte))
+}
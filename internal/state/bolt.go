@@ -0,0 +1,166 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var slotsBucket = []byte("slots")
+
+// BoltStore keeps the slots in an embedded bbolt database, one record per
+// target key, so only the changed target is rewritten. The database file is
+// locked by a single process.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+func NewBolt(path string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(slotsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func readSlots(tx *bolt.Tx, key string) ([]ExamSlot, error) {
+	data := tx.Bucket(slotsBucket).Get([]byte(key))
+	if data == nil {
+		return nil, nil
+	}
+	var slots []ExamSlot
+	err := json.Unmarshal(data, &slots)
+	return slots, err
+}
+
+func writeSlots(tx *bolt.Tx, key string, slots []ExamSlot) error {
+	data, err := json.Marshal(slots)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(slotsBucket).Put([]byte(key), data)
+}
+
+func (s *BoltStore) Get(key string) (slots []ExamSlot, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		slots, err = readSlots(tx, key)
+		return err
+	})
+	return slots, err
+}
+
+func (s *BoltStore) Lookup(key, day, time string) (ExamSlot, bool, error) {
+	slots, err := s.Get(key)
+	if err != nil {
+		return ExamSlot{}, false, err
+	}
+	for _, slot := range slots {
+		if slot.Day == day && slot.Time == time {
+			return slot, true, nil
+		}
+	}
+	return ExamSlot{}, false, nil
+}
+
+func (s *BoltStore) Add(key string, slot ExamSlot) (claimed bool, err error) {
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		slots, err := readSlots(tx, key)
+		if err != nil {
+			return err
+		}
+		for i, existing := range slots {
+			if existing.Day == slot.Day && existing.Time == slot.Time {
+				if existing.Active() {
+					return nil
+				}
+				slots[i] = slot
+				claimed = true
+				return writeSlots(tx, key, slots)
+			}
+		}
+		claimed = true
+		return writeSlots(tx, key, append(slots, slot))
+	})
+	return claimed && err == nil, err
+}
+
+func (s *BoltStore) Release(key, day, time string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		slots, err := readSlots(tx, key)
+		if err != nil {
+			return err
+		}
+		kept := slots[:0]
+		for _, slot := range slots {
+			if slot.Day != day || slot.Time != time {
+				kept = append(kept, slot)
+			}
+		}
+		return writeSlots(tx, key, kept)
+	})
+}
+
+func (s *BoltStore) Sweep(key string, present map[string]bool, now time.Time) (gone []ExamSlot, err error) {
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		slots, err := readSlots(tx, key)
+		if err != nil {
+			return err
+		}
+		for i, slot := range slots {
+			if !slot.Active() || present[SlotID(slot.Day, slot.Time)] {
+				continue
+			}
+			slots[i].GoneAt = now
+			gone = append(gone, slots[i])
+		}
+		if len(gone) == 0 {
+			return nil
+		}
+		return writeSlots(tx, key, slots)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return gone, nil
+}
+
+func (s *BoltStore) Keys() ([]string, error) {
+	var keys []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(slotsBucket).ForEach(func(k, _ []byte) error {
+			keys = append(keys, string(k))
+			return nil
+		})
+	})
+	return keys, err
+}
+
+func (s *BoltStore) Put(key string, slots []ExamSlot) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return writeSlots(tx, key, slots)
+	})
+}
+
+func (s *BoltStore) Delete(key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(slotsBucket).Delete([]byte(key))
+	})
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
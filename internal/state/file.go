@@ -0,0 +1,206 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/atomicfile"
+)
+
+const maxBackups = 3
+
+// FileStore keeps all slots in one JSON file. It cannot be shared between
+// processes. Every change replaces the file atomically and keeps the previous
+// versions as backups.
+type FileStore struct {
+	path   string
+	mu     sync.Mutex
+	latest map[string][]ExamSlot
+}
+
+// NewFile loads the state from path. A missing file starts an empty state; a
+// corrupt one is moved aside and the newest readable backup is used instead.
+func NewFile(path string) (*FileStore, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileStore{
+		path:   abs,
+		latest: make(map[string][]ExamSlot),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStore) load() error {
+	latest, err := readState(s.path)
+	if err == nil {
+		s.latest = latest
+		return nil
+	}
+	missing := errors.Is(err, fs.ErrNotExist)
+	if !missing {
+		slog.Error("Uszkodzony plik stanu, próba odczytu kopii zapasowej", "path", s.path, "err", err)
+	}
+
+	for n := 1; n <= maxBackups; n++ {
+		backup := atomicfile.Backup(s.path, n)
+		latest, berr := readState(backup)
+		if errors.Is(berr, fs.ErrNotExist) {
+			continue
+		}
+		if berr != nil {
+			slog.Error("Uszkodzona kopia zapasowa stanu", "path", backup, "err", berr)
+			continue
+		}
+		if !missing {
+			corrupt := fmt.Sprintf("%s.corrupt-%s", s.path, time.Now().Format("20060102T150405"))
+			if rerr := os.Rename(s.path, corrupt); rerr != nil {
+				return fmt.Errorf("nie można odłożyć uszkodzonego pliku stanu: %w", rerr)
+			}
+			slog.Warn("Uszkodzony plik stanu odłożony", "path", corrupt)
+		}
+		slog.Warn("Stan odtworzony z kopii zapasowej", "path", backup)
+		s.latest = latest
+		return s.Save()
+	}
+
+	if missing {
+		return nil
+	}
+	return fmt.Errorf("plik stanu %s jest uszkodzony i brak poprawnej kopii zapasowej: %w", s.path, err)
+}
+
+func readState(path string) (map[string][]ExamSlot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var latest map[string][]ExamSlot
+	if err := json.Unmarshal(data, &latest); err != nil {
+		return nil, err
+	}
+	if latest == nil {
+		latest = make(map[string][]ExamSlot)
+	}
+	return latest, nil
+}
+
+func (s *FileStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.MarshalIndent(s.latest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.Write(s.path, data, 0644, maxBackups); err != nil {
+		return fmt.Errorf("zapis stanu: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) Get(key string) ([]ExamSlot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ExamSlot(nil), s.latest[key]...), nil
+}
+
+func (s *FileStore) Add(key string, slot ExamSlot) (bool, error) {
+	s.mu.Lock()
+	examSlots := s.latest[key]
+	for i, examSlot := range examSlots {
+		if examSlot.Day == slot.Day && examSlot.Time == slot.Time {
+			if examSlot.Active() {
+				s.mu.Unlock()
+				return false, nil
+			}
+			examSlots[i] = slot
+			s.mu.Unlock()
+			return true, s.Save()
+		}
+	}
+	s.latest[key] = append(s.latest[key], slot)
+	s.mu.Unlock()
+	return true, s.Save()
+}
+
+func (s *FileStore) Release(key, day, time string) error {
+	s.mu.Lock()
+	slots := s.latest[key][:0:0]
+	for _, slot := range s.latest[key] {
+		if slot.Day != day || slot.Time != time {
+			slots = append(slots, slot)
+		}
+	}
+	s.latest[key] = slots
+	s.mu.Unlock()
+	return s.Save()
+}
+
+func (s *FileStore) Lookup(key, day, time string) (ExamSlot, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, slot := range s.latest[key] {
+		if slot.Day == day && slot.Time == time {
+			return slot, true, nil
+		}
+	}
+	return ExamSlot{}, false, nil
+}
+
+func (s *FileStore) Sweep(key string, present map[string]bool, now time.Time) ([]ExamSlot, error) {
+	s.mu.Lock()
+	var gone []ExamSlot
+	for i, slot := range s.latest[key] {
+		if !slot.Active() || present[SlotID(slot.Day, slot.Time)] {
+			continue
+		}
+		s.latest[key][i].GoneAt = now
+		gone = append(gone, s.latest[key][i])
+	}
+	s.mu.Unlock()
+	if len(gone) > 0 {
+		return gone, s.Save()
+	}
+	return gone, nil
+}
+
+func (s *FileStore) Keys() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.latest))
+	for key := range s.latest {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *FileStore) Put(key string, slots []ExamSlot) error {
+	s.mu.Lock()
+	s.latest[key] = append([]ExamSlot(nil), slots...)
+	s.mu.Unlock()
+	return s.Save()
+}
+
+func (s *FileStore) Delete(key string) error {
+	s.mu.Lock()
+	delete(s.latest, key)
+	s.mu.Unlock()
+	return s.Save()
+}
+
+func (s *FileStore) Close() error {
+	return nil
+}
@@ -0,0 +1,138 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/atomicfile"
+)
+
+const backupState = `{"1:B":[{"day":"2030-01-02","time":"08:00:00","practice_ids":["p1"]}]}`
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertRecovered(t *testing.T, s *FileStore) {
+	t.Helper()
+	slot, ok, err := s.Lookup(Key("1", "B"), "2030-01-02", "08:00:00")
+	if err != nil || !ok {
+		t.Fatalf("Lookup = %+v, %t, %v; want the slot from the backup", slot, ok, err)
+	}
+	if len(slot.PracticeIDs) != 1 || slot.PracticeIDs[0] != "p1" {
+		t.Errorf("PracticeIDs = %v, want [p1]", slot.PracticeIDs)
+	}
+}
+
+func TestNewFileMissing(t *testing.T) {
+	s, err := NewFile(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewFile: %v", err)
+	}
+	if keys, _ := s.Keys(); len(keys) != 0 {
+		t.Errorf("Keys() = %v, want an empty state", keys)
+	}
+}
+
+func TestNewFileMissingUsesBackup(t *testing.T) {
+	// A crash between rotating the backups and renaming the new file leaves
+	// only state.json.bak.1.
+	path := filepath.Join(t.TempDir(), "state.json")
+	writeFile(t, atomicfile.Backup(path, 1), backupState)
+
+	s, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("NewFile: %v", err)
+	}
+	assertRecovered(t, s)
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("recovered state not saved: %v", err)
+	}
+}
+
+func TestNewFileCorruptUsesBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	writeFile(t, path, `{"1:B": [`)
+	writeFile(t, atomicfile.Backup(path, 1), "not json")
+	writeFile(t, atomicfile.Backup(path, 2), backupState)
+
+	s, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("NewFile: %v", err)
+	}
+	assertRecovered(t, s)
+
+	corrupt, err := filepath.Glob(path + ".corrupt-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrupt) != 1 {
+		t.Fatalf("corrupt copies = %v, want one", corrupt)
+	}
+	data, err := os.ReadFile(corrupt[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"1:B": [` {
+		t.Errorf("corrupt copy = %q, want the original content", data)
+	}
+	suffix := strings.TrimPrefix(corrupt[0], path+".corrupt-")
+	if _, err := time.Parse("20060102T150405", suffix); err != nil {
+		t.Errorf("corrupt copy suffix %q is not a timestamp: %v", suffix, err)
+	}
+
+	reopened, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("NewFile after recovery: %v", err)
+	}
+	assertRecovered(t, reopened)
+}
+
+func TestNewFileAllBackupsCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	writeFile(t, path, "{")
+	for n := 1; n <= maxBackups; n++ {
+		writeFile(t, atomicfile.Backup(path, n), "{")
+	}
+
+	if _, err := NewFile(path); err == nil {
+		t.Fatal("NewFile succeeded with a corrupt file and corrupt backups")
+	}
+	// Nothing is moved aside, so the files stay for manual inspection.
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("corrupt state file was moved: %v", err)
+	}
+}
+
+func TestFileRelease(t *testing.T) {
+	s, err := NewFile(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := Key("1", "B")
+	for _, hour := range []string{"08:00:00", "09:00:00"} {
+		if claimed, err := s.Add(key, ExamSlot{Day: "2030-01-02", Time: hour}); err != nil || !claimed {
+			t.Fatalf("Add(%s) = %t, %v", hour, claimed, err)
+		}
+	}
+
+	if err := s.Release(key, "2030-01-02", "08:00:00"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if _, ok, _ := s.Lookup(key, "2030-01-02", "08:00:00"); ok {
+		t.Error("released slot is still stored")
+	}
+	if _, ok, _ := s.Lookup(key, "2030-01-02", "09:00:00"); !ok {
+		t.Error("Release removed another slot")
+	}
+	if claimed, err := s.Add(key, ExamSlot{Day: "2030-01-02", Time: "08:00:00"}); err != nil || !claimed {
+		t.Errorf("Add after Release = %t, %v; want the slot claimed again", claimed, err)
+	}
+}
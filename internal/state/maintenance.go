@@ -0,0 +1,141 @@
+package state
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Snapshot is the portable form of a store, the same layout as the state
+// file: slots keyed by target key.
+type Snapshot map[string][]ExamSlot
+
+// KeyStats summarizes the slots stored for one key.
+type KeyStats struct {
+	Key      string
+	Active   int
+	Gone     int
+	First    string
+	Last     string
+	Notified time.Time
+}
+
+// Forget removes one slot so it is reported as new the next time it is seen.
+func Forget(s Store, key, day, time string) (bool, error) {
+	slots, err := s.Get(key)
+	if err != nil {
+		return false, err
+	}
+	kept := slots[:0]
+	for _, slot := range slots {
+		if slot.Day != day || slot.Time != time {
+			kept = append(kept, slot)
+		}
+	}
+	if len(kept) == len(slots) {
+		return false, nil
+	}
+	return true, s.Put(key, kept)
+}
+
+// Prune removes slots dated before the given day and keys left empty. It
+// returns the number of removed slots.
+func Prune(s Store, before time.Time) (int, error) {
+	keys, err := s.Keys()
+	if err != nil {
+		return 0, err
+	}
+	cutoff := before.Format("2006-01-02")
+	removed := 0
+	for _, key := range keys {
+		slots, err := s.Get(key)
+		if err != nil {
+			return removed, err
+		}
+		var kept []ExamSlot
+		for _, slot := range slots {
+			if slot.Day >= cutoff {
+				kept = append(kept, slot)
+			}
+		}
+		if len(kept) == len(slots) {
+			continue
+		}
+		if len(kept) == 0 {
+			err = s.Delete(key)
+		} else {
+			err = s.Put(key, kept)
+		}
+		if err != nil {
+			return removed, err
+		}
+		removed += len(slots) - len(kept)
+	}
+	return removed, nil
+}
+
+func Export(s Store, w io.Writer) error {
+	keys, err := s.Keys()
+	if err != nil {
+		return err
+	}
+	snap := make(Snapshot, len(keys))
+	for _, key := range keys {
+		if snap[key], err = s.Get(key); err != nil {
+			return err
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(snap)
+}
+
+// Import replaces the keys present in the snapshot and returns how many
+// slots were written. Other keys are left untouched.
+func Import(s Store, r io.Reader) (int, error) {
+	var snap Snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return 0, err
+	}
+	n := 0
+	for key, slots := range snap {
+		if err := s.Put(key, slots); err != nil {
+			return n, err
+		}
+		n += len(slots)
+	}
+	return n, nil
+}
+
+func Stats(s Store) ([]KeyStats, error) {
+	keys, err := s.Keys()
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]KeyStats, 0, len(keys))
+	for _, key := range keys {
+		slots, err := s.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		ks := KeyStats{Key: key}
+		for _, slot := range slots {
+			if slot.Active() {
+				ks.Active++
+			} else {
+				ks.Gone++
+			}
+			if ks.First == "" || slot.Day < ks.First {
+				ks.First = slot.Day
+			}
+			if slot.Day > ks.Last {
+				ks.Last = slot.Day
+			}
+			if slot.NotifiedAt.After(ks.Notified) {
+				ks.Notified = slot.NotifiedAt
+			}
+		}
+		stats = append(stats, ks)
+	}
+	return stats, nil
+}
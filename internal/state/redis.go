@@ -0,0 +1,214 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/kapi1023/word-monitor/internal/config"
+)
+
+const (
+	defaultRedisPrefix = "word-monitor:"
+	redisTimeout       = 5 * time.Second
+)
+
+// RedisStore shares the slots between monitor instances. Every target key is
+// a hash of SlotID to slot plus a set of the active slot IDs; adding to the
+// set is what claims a slot, so only one instance notifies about it.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedis(cfg config.RedisState) (*RedisStore, error) {
+	prefix := cfg.Prefix
+	if prefix == "" {
+		prefix = defaultRedisPrefix
+	}
+	client := redis.NewClient(&redis.Options{Addr: cfg.Addr, Password: cfg.Password, DB: cfg.DB})
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &RedisStore{client: client, prefix: prefix}, nil
+}
+
+func (s *RedisStore) slotsKey(key string) string {
+	return s.prefix + "slots:" + key
+}
+
+func (s *RedisStore) activeKey(key string) string {
+	return s.prefix + "active:" + key
+}
+
+func (s *RedisStore) Get(key string) ([]ExamSlot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	values, err := s.client.HGetAll(ctx, s.slotsKey(key)).Result()
+	if err != nil {
+		return nil, err
+	}
+	slots := make([]ExamSlot, 0, len(values))
+	for _, v := range values {
+		var slot ExamSlot
+		if err := json.Unmarshal([]byte(v), &slot); err != nil {
+			return nil, err
+		}
+		slots = append(slots, slot)
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return SlotID(slots[i].Day, slots[i].Time) < SlotID(slots[j].Day, slots[j].Time)
+	})
+	return slots, nil
+}
+
+func (s *RedisStore) Lookup(key, day, time string) (ExamSlot, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	v, err := s.client.HGet(ctx, s.slotsKey(key), SlotID(day, time)).Result()
+	if errors.Is(err, redis.Nil) {
+		return ExamSlot{}, false, nil
+	}
+	if err != nil {
+		return ExamSlot{}, false, err
+	}
+	var slot ExamSlot
+	if err := json.Unmarshal([]byte(v), &slot); err != nil {
+		return ExamSlot{}, false, err
+	}
+	return slot, true, nil
+}
+
+// claimScript adds the slot ID to the active set and stores the slot in the
+// same step, so a claimed slot is never left without its data.
+var claimScript = redis.NewScript(`
+if redis.call("SADD", KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call("HSET", KEYS[2], ARGV[1], ARGV[2])
+return 1
+`)
+
+func (s *RedisStore) Add(key string, slot ExamSlot) (bool, error) {
+	data, err := json.Marshal(slot)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	id := SlotID(slot.Day, slot.Time)
+	added, err := claimScript.Run(ctx, s.client, []string{s.activeKey(key), s.slotsKey(key)}, id, data).Int()
+	if err != nil {
+		return false, err
+	}
+	return added == 1, nil
+}
+
+func (s *RedisStore) Release(key, day, time string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	id := SlotID(day, time)
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SRem(ctx, s.activeKey(key), id)
+		pipe.HDel(ctx, s.slotsKey(key), id)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) Sweep(key string, present map[string]bool, now time.Time) ([]ExamSlot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	active, err := s.client.SMembers(ctx, s.activeKey(key)).Result()
+	if err != nil {
+		return nil, err
+	}
+	var gone []ExamSlot
+	for _, id := range active {
+		if present[id] {
+			continue
+		}
+		// Another instance may sweep the same slot; only the one that
+		// removes it from the active set records it as gone.
+		removed, err := s.client.SRem(ctx, s.activeKey(key), id).Result()
+		if err != nil {
+			return gone, err
+		}
+		if removed == 0 {
+			continue
+		}
+		v, err := s.client.HGet(ctx, s.slotsKey(key), id).Result()
+		if err != nil {
+			return gone, err
+		}
+		var slot ExamSlot
+		if err := json.Unmarshal([]byte(v), &slot); err != nil {
+			return gone, err
+		}
+		slot.GoneAt = now
+		data, err := json.Marshal(slot)
+		if err != nil {
+			return gone, err
+		}
+		if err := s.client.HSet(ctx, s.slotsKey(key), id, data).Err(); err != nil {
+			return gone, err
+		}
+		gone = append(gone, slot)
+	}
+	return gone, nil
+}
+
+func (s *RedisStore) Keys() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	prefix := s.slotsKey("")
+	var keys []string
+	iter := s.client.Scan(ctx, 0, prefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, strings.TrimPrefix(iter.Val(), prefix))
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *RedisStore) Put(key string, slots []ExamSlot) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, s.slotsKey(key), s.activeKey(key))
+		for _, slot := range slots {
+			data, err := json.Marshal(slot)
+			if err != nil {
+				return err
+			}
+			id := SlotID(slot.Day, slot.Time)
+			pipe.HSet(ctx, s.slotsKey(key), id, data)
+			if slot.Active() {
+				pipe.SAdd(ctx, s.activeKey(key), id)
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) Delete(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return s.client.Del(ctx, s.slotsKey(key), s.activeKey(key)).Err()
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
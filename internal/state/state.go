@@ -1,91 +1,86 @@
 package state
 
 import (
-	"encoding/json"
-	"os"
-	"path/filepath"
-	"sync"
+	"fmt"
+	"time"
+
+	"github.com/kapi1023/word-monitor/internal/config"
 )
 
 type ExamSlot struct {
-	Day         string   `json:"day"`
-	Time        string   `json:"time"`
-	PracticeIDs []string `json:"practice_ids"`
-	TheoryIDs   []string `json:"theory_ids"`
-}
-
-type Storage struct {
-	path   string
-	mu     sync.Mutex
-	latest map[string][]ExamSlot
+	Day         string    `json:"day"`
+	Time        string    `json:"time"`
+	PracticeIDs []string  `json:"practice_ids"`
+	TheoryIDs   []string  `json:"theory_ids"`
+	Exams       []Exam    `json:"exams,omitempty"`
+	NotifiedAt  time.Time `json:"notified_at"`
+	GoneAt      time.Time `json:"gone_at"`
 }
 
-func New(path, _ string) (*Storage, error) {
-	abs, _ := filepath.Abs(path)
-	_ = os.MkdirAll(filepath.Dir(abs), 0755)
-	if _, err := os.Stat(abs); os.IsNotExist(err) {
-		_ = os.WriteFile(abs, []byte("{}"), 0644)
-	}
-	s := &Storage{
-		path:   abs,
-		latest: make(map[string][]ExamSlot),
-	}
-	_ = s.load()
-	return s, nil
+type Exam struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	Date   string `json:"date"`
+	Places int    `json:"places"`
+	Amount int    `json:"amount"`
+	Info   string `json:"info,omitempty"`
 }
 
-func (s *Storage) load() error {
-	data, err := os.ReadFile(s.path)
-	if err != nil {
-		return nil
-	}
-	return json.Unmarshal(data, &s.latest)
+// Active reports whether the slot was still present in the last checked schedule.
+func (e ExamSlot) Active() bool {
+	return e.GoneAt.IsZero()
 }
 
-func (s *Storage) Save() error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	data, err := json.MarshalIndent(s.latest, "", "  ")
-	if err != nil {
-		return err
-	}
-	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
-		return err
-	}
-	return os.WriteFile(s.path, data, 0644)
+// Store keeps the slots seen for every target key. Add is atomic, so monitor
+// instances sharing a store notify about a slot only once.
+type Store interface {
+	// Get returns all slots of key, including the ones that are gone.
+	Get(key string) ([]ExamSlot, error)
+	// Lookup returns the stored slot regardless of whether it is still active.
+	Lookup(key, day, time string) (ExamSlot, bool, error)
+	// Add stores the slot as active and reports whether this call claimed it,
+	// i.e. the slot was new or gone before. A gone slot is reactivated with
+	// the new exam IDs and notification time.
+	Add(key string, slot ExamSlot) (bool, error)
+	// Release forgets a slot claimed by Add whose notification could not be
+	// sent, so the next Add claims it again.
+	Release(key, day, time string) error
+	// Sweep marks every active slot of key that is not in present as gone and
+	// returns the slots that disappeared. present is keyed by SlotID.
+	Sweep(key string, present map[string]bool, now time.Time) ([]ExamSlot, error)
+	// Keys lists the target keys with stored slots, sorted.
+	Keys() ([]string, error)
+	// Put replaces all slots of key.
+	Put(key string, slots []ExamSlot) error
+	// Delete removes key with all its slots.
+	Delete(key string) error
+	Close() error
 }
 
-func (s *Storage) Get(key string) []ExamSlot {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	return s.latest[key]
-}
-
-func (s *Storage) Add(key string, slot ExamSlot) {
-	s.mu.Lock()
-	examSlots := s.latest[key]
-	for _, examSlot := range examSlots {
-		if examSlot.Day == slot.Day && examSlot.Time == slot.Time {
-			s.mu.Unlock()
-			return
-		}
-	}
-	s.latest[key] = append(s.latest[key], slot)
-	s.mu.Unlock()
-	_ = s.Save()
-}
+const (
+	BackendFile  = "file"
+	BackendBolt  = "bolt"
+	BackendRedis = "redis"
+)
 
-func (s *Storage) Exists(key, day, time string) bool {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	for _, slot := range s.latest[key] {
-		if slot.Day == day && slot.Time == time {
-			return true
-		}
+// Open creates the store selected in the config. path is used by the file
+// and bolt backends.
+func Open(cfg config.State, path string) (Store, error) {
+	switch cfg.Backend {
+	case "", BackendFile:
+		return NewFile(path)
+	case BackendBolt:
+		return NewBolt(path)
+	case BackendRedis:
+		return NewRedis(cfg.Redis)
 	}
-	return false
+	return nil, fmt.Errorf("nieznany backend stanu %q", cfg.Backend)
 }
 
 func Key(wordID, category string) string {
 	return wordID + ":" + category
 }
+
+func SlotID(day, time string) string {
+	return day + " " + time
+}
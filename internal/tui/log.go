@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const maxLogLines = 200
+
+// logBuffer keeps the last log lines so they can be drawn inside the
+// dashboard instead of scrolling the alternate screen.
+type logBuffer struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		b.lines = append(b.lines, string(line))
+	}
+	if len(b.lines) > maxLogLines {
+		b.lines = b.lines[len(b.lines)-maxLogLines:]
+	}
+	return len(p), nil
+}
+
+// Last returns up to n most recent lines, oldest first.
+func (b *logBuffer) Last(n int) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.lines) < n {
+		n = len(b.lines)
+	}
+	return append([]string(nil), b.lines[len(b.lines)-n:]...)
+}
+
+func (b *logBuffer) handler(level slog.Level) slog.Handler {
+	return slog.NewTextHandler(b, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.String(slog.TimeKey, a.Value.Time().Format("15:04:05"))
+			}
+			return a
+		},
+	})
+}
+
+// plain turns a Discord message into a single terminal line: markdown
+// markers and emoji are dropped and line breaks become separators.
+func plain(message string) string {
+	var b strings.Builder
+	for _, r := range message {
+		switch {
+		case r == '*' || r == '`' || r == '_':
+		case r == '\n':
+			b.WriteString(" | ")
+		case unicode.Is(unicode.So, r) || r == '\uFE0F':
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
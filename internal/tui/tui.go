@@ -0,0 +1,490 @@
+// Package tui draws a full-screen dashboard for a running monitor.
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"github.com/kapi1023/word-monitor/internal/catalog"
+	"github.com/kapi1023/word-monitor/internal/config"
+	"github.com/kapi1023/word-monitor/internal/logging"
+	"github.com/kapi1023/word-monitor/internal/monitor"
+	"github.com/kapi1023/word-monitor/internal/state"
+)
+
+const (
+	calendarDays = 14
+	refreshEvery = time.Second
+	maxEvents    = 6
+	maxLogs      = 6
+)
+
+type Dashboard struct {
+	configPath string
+	poller     *monitor.Poller
+	storage    state.Store
+	catalog    *catalog.Catalog
+	logs       logBuffer
+
+	// AfterEdit is called with the edited configuration before the poller
+	// uses it, e.g. to validate the WORD IDs.
+	AfterEdit func(cfg *config.Config)
+
+	fd       int
+	raw      *term.State
+	selected int
+	status   string
+	running  bool
+	done     chan error
+}
+
+// New shows poller, which owns the configuration while the dashboard runs.
+func New(configPath string, poller *monitor.Poller, storage state.Store, words *catalog.Catalog) *Dashboard {
+	return &Dashboard{
+		configPath: configPath,
+		poller:     poller,
+		storage:    storage,
+		catalog:    words,
+		fd:         int(os.Stdin.Fd()),
+		done:       make(chan error, 1),
+	}
+}
+
+// Run starts the poller and shows the dashboard until the user quits. The
+// poller is stopped before Run returns.
+func (d *Dashboard) Run() error {
+	if !term.IsTerminal(d.fd) {
+		return errors.New("panel wymaga terminala")
+	}
+	prev := slog.Default()
+	slog.SetDefault(slog.New(logging.Redact(d.logs.handler(logging.Level()))))
+	defer slog.SetDefault(prev)
+
+	if err := d.enter(); err != nil {
+		return err
+	}
+	d.start()
+
+	// Stdin is only read after the previous key was handled, so prompts and
+	// config editing get the terminal to themselves. The reader exits once
+	// Run has returned, at the latest after the next key.
+	keys := make(chan []byte)
+	next := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		buf := make([]byte, 16)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			select {
+			case keys <- append([]byte(nil), buf[:n]...):
+			case <-done:
+				return
+			}
+			select {
+			case <-next:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(refreshEvery)
+	defer ticker.Stop()
+	d.render()
+	for {
+		select {
+		case <-ticker.C:
+		case err := <-d.done:
+			d.running = false
+			if err != nil {
+				d.status = "Monitoring zatrzymany: " + err.Error()
+			}
+		case key, ok := <-keys:
+			if !ok || d.handle(key) {
+				return d.quit()
+			}
+			next <- struct{}{}
+		}
+		d.render()
+	}
+}
+
+func (d *Dashboard) start() {
+	d.running = true
+	d.status = ""
+	go func() { d.done <- d.poller.Run() }()
+}
+
+func (d *Dashboard) quit() error {
+	d.leave()
+	if !d.running {
+		return nil
+	}
+	fmt.Println("Zatrzymywanie monitoringu...")
+	d.poller.Stop()
+	if err := <-d.done; err != nil && !errors.Is(err, monitor.ErrStopped) {
+		return err
+	}
+	return nil
+}
+
+func (d *Dashboard) enter() error {
+	raw, err := term.MakeRaw(d.fd)
+	if err != nil {
+		return err
+	}
+	d.raw = raw
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	return nil
+}
+
+func (d *Dashboard) leave() {
+	fmt.Print("\x1b[?25h\x1b[?1049l")
+	if d.raw != nil {
+		_ = term.Restore(d.fd, d.raw)
+		d.raw = nil
+	}
+}
+
+// handle reacts to a key press and reports whether the dashboard should close.
+func (d *Dashboard) handle(key []byte) bool {
+	targets := d.poller.Targets()
+	switch string(key) {
+	case "q", "Q", "\x03":
+		return true
+	case "p", "P":
+		switch {
+		case !d.running:
+			d.start()
+		case d.poller.Paused():
+			d.poller.Resume()
+			d.status = "Monitoring wznowiony"
+		default:
+			d.poller.Pause()
+			d.status = "Monitoring wstrzymany"
+		}
+	case "r", "R":
+		if !d.running {
+			d.start()
+			break
+		}
+		d.poller.CheckNow()
+		d.status = "Sprawdzanie na żądanie"
+	case "a", "A":
+		d.prompt(d.addTarget)
+	case "e", "E":
+		d.prompt(func() {
+			d.poller.Exclusive(func(cfg *config.Config) {
+				cfg.Edit()
+				if d.AfterEdit != nil {
+					d.AfterEdit(cfg)
+				}
+			})
+			d.status = "Konfiguracja zmieniona (niezapisana)"
+		})
+	case "s", "S":
+		cfg := d.poller.Config()
+		if err := cfg.Save(d.configPath); err != nil {
+			d.status = "Błąd zapisu konfiguracji: " + err.Error()
+		} else {
+			d.status = "Konfiguracja zapisana"
+		}
+	case "j", "\x1b[B":
+		if d.selected < len(targets)-1 {
+			d.selected++
+		}
+	case "k", "\x1b[A":
+		if d.selected > 0 {
+			d.selected--
+		}
+	}
+	return false
+}
+
+// prompt leaves the dashboard for the duration of fn so it can use the
+// terminal in line mode.
+func (d *Dashboard) prompt(fn func()) {
+	d.leave()
+	fn()
+	if err := d.enter(); err != nil {
+		d.status = err.Error()
+	}
+}
+
+func (d *Dashboard) addTarget() {
+	fmt.Println("\n--- NOWY CEL ---")
+	query := readLine("ID lub nazwa WORDu: ")
+	if query == "" {
+		return
+	}
+	cfg := d.poller.Config()
+	if err := d.catalog.EnsureFresh(context.Background(), cfg.CatalogRefresh()); err != nil {
+		slog.Warn("Katalog WORD niedostępny", "err", err)
+	}
+	word, err := d.catalog.Resolve(query)
+	if err != nil {
+		d.status = "Nie dodano celu: " + err.Error()
+		return
+	}
+	category := strings.ToUpper(readLine("Kategoria [B]: "))
+	if category == "" {
+		category = "B"
+	}
+	maxDays := cfg.Word.MaxDays
+	if v := readLine(fmt.Sprintf("Max dni do egzaminu [%d]: ", maxDays)); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			d.status = "Nie dodano celu: nieprawidłowa liczba dni"
+			return
+		}
+		maxDays = n
+	}
+	d.poller.AddTarget(config.WORD{WordId: strconv.Itoa(word.ID), Category: category, MaxDays: maxDays})
+	d.status = fmt.Sprintf("Dodano cel %s (%d), kategoria %s", word.Name, word.ID, category)
+	if !d.running {
+		d.start()
+	}
+}
+
+// readLine reads a line byte by byte so no input is buffered past it.
+func readLine(label string) string {
+	fmt.Print(label)
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil || (n == 1 && buf[0] == '\n') {
+			break
+		}
+		line = append(line, buf[:n]...)
+	}
+	return strings.TrimSpace(string(line))
+}
+
+func (d *Dashboard) render() {
+	width, height, err := term.GetSize(d.fd)
+	if err != nil {
+		width, height = 100, 40
+	}
+	now := time.Now()
+	stats := d.poller.Stats()
+	targets := d.poller.Targets()
+	if d.selected >= len(targets) {
+		d.selected = max(len(targets)-1, 0)
+	}
+
+	var lines []string
+	add := func(format string, args ...any) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	add("\x1b[1mWORD MONITOR\x1b[0m  %s  %s", d.phase(stats, now), uptime(stats, now))
+	add("[q] wyjście  [p] pauza/wznów  [r] sprawdź teraz  [a] dodaj cel  [e] edytuj konfigurację  [s] zapisz  [j/k] wybór")
+	if d.status != "" {
+		add("\x1b[33m%s\x1b[0m", d.status)
+	}
+	add("")
+
+	add("\x1b[1mCELE\x1b[0m")
+	add("  %-6s %-32s %-4s %-8s %6s %6s  %s", "ID", "WORD", "KAT", "STAN", "SPR", "BŁĘDY", "OSTATNI SUKCES")
+	for i, t := range targets {
+		ts := stats.Targets[state.Key(t.WordId, t.Category)]
+		cursor := " "
+		if i == d.selected {
+			cursor = ">"
+		}
+		status := "ok"
+		switch {
+		case ts.Offline:
+			status = "offline"
+		case ts.Checks == 0:
+			status = "-"
+		case ts.LastError != "" && ts.LastSuccess.IsZero():
+			status = "błąd"
+		}
+		add("%s %-6s %-32s %-4s %-8s %6d %6d  %s", cursor, t.WordId, fit(d.wordName(t.WordId), 32), t.Category, status, ts.Checks, ts.Errors, clock(ts.LastSuccess))
+	}
+	if len(targets) == 0 {
+		add("  brak celów, dodaj WORD klawiszem [a]")
+	}
+	add("")
+
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	header := fmt.Sprintf("  %-39s", fmt.Sprintf("WOLNE TERMINY od %s", start.Format("02.01")))
+	for i := 0; i < calendarDays; i++ {
+		header += fmt.Sprintf(" %2d", start.AddDate(0, 0, i).Day())
+	}
+	add("\x1b[1m%s\x1b[0m", header)
+	for _, t := range targets {
+		counts := make([]int, calendarDays)
+		for _, slot := range d.slots(t) {
+			day, err := time.ParseInLocation("2006-01-02", slot.Day, now.Location())
+			if err != nil || !slot.Active() {
+				continue
+			}
+			if i := int(day.Sub(start).Hours() / 24); i >= 0 && i < calendarDays {
+				counts[i]++
+			}
+		}
+		row := fmt.Sprintf("  %-39s", fit(d.wordName(t.WordId)+" "+t.Category, 39))
+		for _, c := range counts {
+			if c == 0 {
+				row += "  ."
+			} else {
+				row += fmt.Sprintf(" %2d", c)
+			}
+		}
+		add("%s", row)
+	}
+	if len(targets) > 0 {
+		t := targets[d.selected]
+		add("  Terminy: %s %s", d.wordName(t.WordId), t.Category)
+		for _, day := range activeDays(d.slots(t)) {
+			add("    %s", day)
+		}
+	}
+	add("")
+
+	add("\x1b[1mPOWIADOMIENIA I BŁĘDY\x1b[0m")
+	events := d.poller.Events()
+	if len(events) > maxEvents {
+		events = events[len(events)-maxEvents:]
+	}
+	for _, e := range events {
+		add("  %s %-13s %s", e.Time.Format("15:04:05"), e.Kind, plain(e.Text))
+	}
+	if len(events) == 0 {
+		add("  brak")
+	}
+	add("")
+
+	add("\x1b[1mLOGI\x1b[0m")
+	for _, l := range d.logs.Last(maxLogs) {
+		add("  %s", l)
+	}
+
+	if len(lines) > height-1 {
+		lines = lines[:height-1]
+	}
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	for _, l := range lines {
+		b.WriteString(clip(l, width))
+		b.WriteString("\x1b[0m\r\n")
+	}
+	fmt.Print(b.String())
+}
+
+func (d *Dashboard) phase(stats monitor.Stats, now time.Time) string {
+	switch {
+	case !d.running:
+		return "\x1b[31mzatrzymany\x1b[0m"
+	case stats.Paused:
+		return "\x1b[33mpauza\x1b[0m"
+	case !stats.NextCheck.IsZero():
+		return fmt.Sprintf("%s, następne sprawdzenie za %s", stats.Phase, stats.NextCheck.Sub(now).Round(time.Second))
+	case stats.Phase != "":
+		return stats.Phase
+	}
+	return "start"
+}
+
+func uptime(stats monitor.Stats, now time.Time) string {
+	if stats.Started.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("działa od %s, ostatni sukces %s", now.Sub(stats.Started).Round(time.Second), clock(stats.LastSuccess))
+}
+
+func (d *Dashboard) slots(t config.WORD) []state.ExamSlot {
+	slots, err := d.storage.Get(state.Key(t.WordId, t.Category))
+	if err != nil {
+		d.status = "Błąd odczytu stanu: " + err.Error()
+	}
+	return slots
+}
+
+func (d *Dashboard) wordName(id string) string {
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return id
+	}
+	if word, ok := d.catalog.ByID(n); ok {
+		return word.Name
+	}
+	return "WORD " + id
+}
+
+// activeDays lists the active slot times grouped by day.
+func activeDays(slots []state.ExamSlot) []string {
+	var days []string
+	byDay := make(map[string][]string)
+	for _, slot := range slots {
+		if !slot.Active() {
+			continue
+		}
+		if _, ok := byDay[slot.Day]; !ok {
+			days = append(days, slot.Day)
+		}
+		byDay[slot.Day] = append(byDay[slot.Day], slot.Time)
+	}
+	sort.Strings(days)
+	out := make([]string, 0, len(days))
+	for _, day := range days {
+		sort.Strings(byDay[day])
+		out = append(out, day+": "+strings.Join(byDay[day], ", "))
+	}
+	return out
+}
+
+func clock(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("15:04:05")
+}
+
+func fit(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+// clip cuts a line to the terminal width, not counting escape sequences.
+func clip(s string, width int) string {
+	var b strings.Builder
+	visible, escape := 0, false
+	for _, r := range s {
+		switch {
+		case r == '\x1b':
+			escape = true
+		case escape:
+			if r == 'm' {
+				escape = false
+			}
+		default:
+			if visible >= width {
+				continue
+			}
+			visible++
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,114 @@
+// Package vault keeps named secrets, e.g. the info-car password and PESEL,
+// in a file encrypted with a master passphrase, so the config only refers to
+// them by name.
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/kapi1023/word-monitor/internal/atomicfile"
+	"github.com/kapi1023/word-monitor/internal/secretbox"
+)
+
+// EnvKey holds the master passphrase for unattended runs.
+const EnvKey = "WORD_MONITOR_VAULT_KEY"
+
+const version = 1
+
+var additionalData = []byte("word-monitor vault v1")
+
+type file struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Data    []byte `json:"data"`
+}
+
+type Vault struct {
+	path    string
+	salt    []byte
+	key     []byte
+	secrets map[string]string
+}
+
+// Open decrypts the vault at path with passphrase. A missing file gives an
+// empty vault, created on the first Save.
+func Open(path, passphrase string) (*Vault, error) {
+	v := &Vault{path: path, secrets: make(map[string]string)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if v.salt, err = secretbox.NewSalt(); err != nil {
+			return nil, err
+		}
+		v.key, err = secretbox.Key(passphrase, v.salt)
+		return v, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("vault %s: %w", path, err)
+	}
+	if f.Version != version {
+		return nil, fmt.Errorf("vault %s: unsupported version %d", path, f.Version)
+	}
+	v.salt = f.Salt
+	if v.key, err = secretbox.Key(passphrase, v.salt); err != nil {
+		return nil, err
+	}
+	plain, err := secretbox.Open(v.key, f.Data, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("vault %s: %w", path, err)
+	}
+	if err := json.Unmarshal(plain, &v.secrets); err != nil {
+		return nil, fmt.Errorf("vault %s: %w", path, err)
+	}
+	return v, nil
+}
+
+func (v *Vault) Get(name string) (string, bool) {
+	value, ok := v.secrets[name]
+	return value, ok
+}
+
+func (v *Vault) Set(name, value string) {
+	v.secrets[name] = value
+}
+
+func (v *Vault) Delete(name string) bool {
+	_, ok := v.secrets[name]
+	delete(v.secrets, name)
+	return ok
+}
+
+// Names lists the stored secrets in order.
+func (v *Vault) Names() []string {
+	names := make([]string, 0, len(v.secrets))
+	for name := range v.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Save encrypts the secrets and replaces the vault file atomically.
+func (v *Vault) Save() error {
+	plain, err := json.Marshal(v.secrets)
+	if err != nil {
+		return err
+	}
+	sealed, err := secretbox.Seal(v.key, plain, additionalData)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(file{Version: version, Salt: v.salt, Data: sealed}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(v.path, data, 0600, 0)
+}
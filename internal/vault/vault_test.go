@@ -0,0 +1,112 @@
+package vault
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSaveAndOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.enc")
+	v, err := Open(path, "correct horse")
+	if err != nil {
+		t.Fatalf("Open new vault: %v", err)
+	}
+	if len(v.Names()) != 0 {
+		t.Fatalf("new vault holds %v", v.Names())
+	}
+	v.Set("infocar", "hasło-ąę")
+	v.Set("pesel", "02270803628")
+	if err := v.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("02270803628")) || bytes.Contains(data, []byte("infocar")) {
+		t.Error("vault file contains plain text")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	reopened, err := Open(path, "correct horse")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if got := reopened.Names(); !slices.Equal(got, []string{"infocar", "pesel"}) {
+		t.Errorf("Names() = %v", got)
+	}
+	if value, ok := reopened.Get("infocar"); !ok || value != "hasło-ąę" {
+		t.Errorf("Get(infocar) = %q, %t", value, ok)
+	}
+
+	// Saving again keeps the salt, so the same passphrase still opens it.
+	if !reopened.Delete("pesel") || reopened.Delete("pesel") {
+		t.Error("Delete reported the wrong result")
+	}
+	if err := reopened.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	again, err := Open(path, "correct horse")
+	if err != nil {
+		t.Fatalf("Open after second Save: %v", err)
+	}
+	if _, ok := again.Get("pesel"); ok {
+		t.Error("deleted secret is still stored")
+	}
+}
+
+func TestOpenWrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.enc")
+	v, err := Open(path, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Set("infocar", "secret")
+	if err := v.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(path, "battery staple"); err == nil {
+		t.Fatal("Open with a wrong passphrase succeeded")
+	}
+}
+
+func TestOpenTampered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.enc")
+	v, err := Open(path, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Set("infocar", "secret")
+	if err := v.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Flip a character of the base64 ciphertext.
+	i := bytes.Index(data, []byte(`"data": "`)) + len(`"data": "`) + 10
+	if data[i] == 'A' {
+		data[i] = 'B'
+	} else {
+		data[i] = 'A'
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(path, "correct horse"); err == nil {
+		t.Fatal("Open of a modified vault succeeded")
+	}
+}
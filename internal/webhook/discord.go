@@ -2,17 +2,42 @@ package webhook
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"log/slog"
 	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kapi1023/word-monitor/internal/tracing"
 )
 
 type discordPayload struct {
 	Content string `json:"content"`
 }
 
+var tracer = otel.Tracer("github.com/kapi1023/word-monitor/internal/webhook")
+
+var client = http.DefaultClient
+
+// SetClient replaces the HTTP client used for webhooks.
+func SetClient(c *http.Client) {
+	client = c
+}
+
 func Send(discordUrl string, message string) error {
+	return SendContext(context.Background(), discordUrl, message)
+}
+
+// SendContext posts the message to Discord in a "webhook.Send" span.
+func SendContext(ctx context.Context, discordUrl string, message string) (err error) {
+	ctx, span := tracer.Start(ctx, "webhook.Send", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("message.length", len(message))))
+	defer func() { tracing.End(span, err) }()
+
 	if discordUrl == "" {
 		return errors.New("brakuje adresu Discord webhook")
 	}
@@ -25,23 +50,24 @@ func Send(discordUrl string, message string) error {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", discordUrl, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", discordUrl, bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 
 	if resp.StatusCode >= 300 {
-		slog.Error("Błąd webhooka Discord", "status", resp.Status)
+		slog.ErrorContext(ctx, "Błąd webhooka Discord", "status", resp.Status)
 		return errors.New("błąd wysyłki webhooka Discord")
 	}
 
-	slog.Info("Wysłano powiadomienie do Discorda")
+	slog.InfoContext(ctx, "Wysłano powiadomienie do Discorda")
 	return nil
 }